@@ -1,51 +1,288 @@
 package main
 
 import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"log/slog"
+	"net"
+	"os"
+	"os/signal"
+	"syscall"
 	"time"
 
+	"fibre_rate_limit_service/internal/audit"
+	"fibre_rate_limit_service/internal/config"
+	"fibre_rate_limit_service/internal/config/configfile"
+	"fibre_rate_limit_service/internal/decision"
+	"fibre_rate_limit_service/internal/health"
+	"fibre_rate_limit_service/internal/hotkeys"
 	"fibre_rate_limit_service/internal/http"
+	"fibre_rate_limit_service/internal/http/middleware"
 	"fibre_rate_limit_service/internal/limiters"
+	"fibre_rate_limit_service/internal/metrics"
 	"fibre_rate_limit_service/internal/policies"
-	"fibre_rate_limit_service/internal/storage"
+	"fibre_rate_limit_service/internal/rpc"
+	"fibre_rate_limit_service/internal/stream"
+	"fibre_rate_limit_service/internal/tracing"
 
 	"github.com/gofiber/fiber/v2"
+	"google.golang.org/grpc"
 )
 
 func main() {
-	// 1️⃣ Create Fiber app
+	if err := run(context.Background(), os.Args[1:]); err != nil {
+		config.Logger.Error("server error", "err", err)
+		os.Exit(1)
+	}
+}
+
+// run serves until ctx is done or SIGINT/SIGTERM arrives, then shuts down:
+// /readyz starts failing, in-flight requests drain for up to -drain-timeout,
+// and storage, the audit log and the trace exporter are flushed and closed.
+func run(ctx context.Context, args []string) error {
+	flags := flag.NewFlagSet("server", flag.ContinueOnError)
+	addr := flags.String("addr", ":8080", "HTTP listen address")
+	grpcAddr := flags.String("grpc-addr", ":9090", "gRPC listen address (empty disables gRPC)")
+	configPath := flags.String("config", os.Getenv("RATE_LIMIT_CONFIG"), "YAML or JSON config file (default $RATE_LIMIT_CONFIG; built-in sample when empty)")
+	historySize := flags.Int("config-history", http.DefaultHistorySize, "number of config versions kept for rollback")
+	configPoll := flags.Duration("config-poll", 5*time.Second, "how often to check the config file for changes (0 disables; SIGHUP always reloads)")
+	auditPath := flags.String("audit-log", os.Getenv("RATE_LIMIT_AUDIT_LOG"), "append admin actions as JSON lines to this file (default $RATE_LIMIT_AUDIT_LOG; stdout when empty)")
+	adminAddr := flags.String("admin-addr", "", "serve /admin on this address instead of the main listener")
+	adminAuthPath := flags.String("admin-auth", os.Getenv("RATE_LIMIT_ADMIN_AUTH"), "admin credentials file (default $RATE_LIMIT_ADMIN_AUTH; admin API is open when empty)")
+	logLevel := flags.String("log-level", config.SafeString(os.Getenv("RATE_LIMIT_LOG_LEVEL"), "info"), "minimum log level: debug (every decision), info (denials), warn or error (default $RATE_LIMIT_LOG_LEVEL or info)")
+	traceExporter := flags.String("trace-exporter", os.Getenv("RATE_LIMIT_TRACE_EXPORTER"), "export OpenTelemetry spans for check requests: none, stdout, file:<path> or otlp (OTEL_EXPORTER_OTLP_* settings) (default $RATE_LIMIT_TRACE_EXPORTER; none when empty)")
+	traceSample := flags.Float64("trace-sample", 1, "fraction of new traces recorded; callers' traceparent sampling is honored")
+	shutdownDelay := flags.Duration("shutdown-delay", 0, "on SIGTERM, keep serving this long with /readyz failing, so load balancers stop sending traffic first")
+	drainTimeout := flags.Duration("drain-timeout", 15*time.Second, "how long shutdown waits for in-flight requests")
+	if err := flags.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return nil
+		}
+		return err
+	}
+
+	ctx, stopSignals := signal.NotifyContext(ctx, os.Interrupt, syscall.SIGTERM)
+	defer stopSignals()
+
+	// 0️⃣ Log JSON lines at the chosen level, including the standard logger's
+	level, err := config.ParseLogLevel(*logLevel)
+	if err != nil {
+		return err
+	}
+	config.LogLevel.Set(level)
+	slog.SetDefault(config.Logger)
+
+	// Export traces of check requests, continuing callers' W3C trace context
+	shutdownTracing, err := tracing.Setup(context.Background(), tracing.Config{
+		Exporter:    *traceExporter,
+		SampleRatio: *traceSample,
+	})
+	if err != nil {
+		return fmt.Errorf("tracing: %w", err)
+	}
+	defer func() {
+		flushCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if err := shutdownTracing(flushCtx); err != nil {
+			config.Logger.Error("trace exporter flush failed", "err", err)
+		}
+	}()
+
+	// 1️⃣ Load config, failing fast on errors
+	cfg := configfile.Default()
+	if *configPath != "" {
+		if cfg, err = configfile.Load(*configPath); err != nil {
+			return err
+		}
+	}
+
+	var adminAuth *middleware.AdminAuth
+	if *adminAuthPath != "" {
+		if adminAuth, err = middleware.LoadAdminAuth(*adminAuthPath); err != nil {
+			return fmt.Errorf("admin auth: %w", err)
+		}
+	} else {
+		config.Logger.Warn("admin API is unauthenticated; set -admin-auth to protect it")
+	}
+
+	auditLog := audit.New(os.Stdout)
+	if *auditPath != "" {
+		if auditLog, err = audit.Open(*auditPath); err != nil {
+			return fmt.Errorf("audit log: %w", err)
+		}
+	}
+	defer func() {
+		if err := auditLog.Close(); err != nil {
+			config.Logger.Error("audit log flush failed", "err", err)
+		}
+	}()
+
+	// 2️⃣ Create Fiber app, giving every request an ID
 	app := fiber.New()
+	app.Use(middleware.RequestContext())
 
-	// 2️⃣ Create sharded storage for token buckets
-	store := storage.NewShardedMap(16, 10*time.Second, 5*time.Second)
+	// 3️⃣ Create sharded storage for limiter state; closing it stops the
+	// janitor
+	store := cfg.Storage.NewStore()
 	defer store.Close()
 
-	// 3️⃣ Create limiter manager
+	// 4️⃣ Create limiter manager, policy evaluator and decider
 	lm := limiters.NewManager()
-
-	// 4️⃣ Create policy evaluator
 	pe := policies.NewEvaluator()
+	d := decision.NewDecider(lm, pe)
 
-	// 5️⃣ Add a sample policy rule for /check
-	pe.AddRule("/check", policies.Rule{
-		Header: "X-Secret",
-		Value:  "123",
-	})
+	// 5️⃣ Install configured limiters, policies and key extractor. /readyz
+	// reports ready from here on while storage and its janitor are healthy.
+	ready := health.NewReadiness()
+	ready.AddStore(store)
+	if _, err := cfg.Apply(lm, pe, d, store); err != nil {
+		return err
+	}
+	ready.ConfigLoaded()
+
+	// Log decisions, count them and storage activity for /metrics, track the
+	// hottest keys per limiter, and stream decisions to /admin/stream
+	d.Observe(decision.LogObserver(config.Logger))
+	m := metrics.New()
+	m.Instrument(d, store)
+	hotKeys := hotkeys.New(hotkeys.DefaultCapacity, hotkeys.DefaultSlot, hotkeys.DefaultSlots)
+	d.Observe(hotKeys.Observe)
+	decisions := stream.NewHub()
+	d.Observe(decisions.Observe)
+
+	// 6️⃣ Record config versions, and reload the config file when it changes
+	// or on SIGHUP
+	history := configfile.NewHistory(*historySize, lm, pe, d)
+	if *configPath != "" {
+		reloader := configfile.NewReloader(*configPath, cfg, lm, pe, d, store)
+		reloader.History = history
+		reloader.Readiness = ready
+		stop := make(chan struct{})
+		defer close(stop)
+		if *configPoll > 0 {
+			go reloader.Watch(*configPoll, stop)
+		}
+
+		hup := make(chan os.Signal, 1)
+		signal.Notify(hup, syscall.SIGHUP)
+		defer signal.Stop(hup)
+		go func() {
+			for {
+				select {
+				case <-hup:
+					reloader.ReloadAndLog("SIGHUP")
+				case <-stop:
+					return
+				}
+			}
+		}()
+	}
+
+	// 7️⃣ Setup routes, auditing admin actions. The admin routes get their
+	// own listener when -admin-addr is set. A listener failing stops the
+	// service.
+	serveErr := make(chan error, 3)
+	services := http.Services{
+		Limiters:  lm,
+		Policies:  pe,
+		Store:     store,
+		Audit:     auditLog,
+		Decider:   d,
+		History:   history,
+		AdminAuth: adminAuth,
+		HotKeys:   hotKeys,
+		Stream:    decisions,
+		Metrics:   m,
+		Tracing:   *traceExporter != "" && *traceExporter != "none",
+		Readiness: ready,
+	}
+	var adminApp *fiber.App
+	if *adminAddr == "" {
+		http.SetupRouter(app, services)
+	} else {
+		http.SetupCheckRoutes(app, services)
+		adminApp = fiber.New()
+		adminApp.Use(middleware.RequestContext())
+		http.SetupAdminRoutes(adminApp, services)
+		go func() {
+			if err := adminApp.Listen(*adminAddr); err != nil {
+				serveErr <- fmt.Errorf("admin server: %w", err)
+			}
+		}()
+	}
 
-	// 6️⃣ Create a token bucket limiter for /check
-	tb := limiters.NewTokenBucket(limiters.TokenBucketConfig{
-		Name:        "/check",
-		Capacity:    5,
-		RefillRate:  1,
-		RefillEvery: 2 * time.Second,
-		TTL:         30 * time.Second,
-	}, store)
+	// 8️⃣ Start gRPC server sharing the same limiters and policies
+	var gs *grpc.Server
+	if *grpcAddr != "" {
+		lis, err := net.Listen("tcp", *grpcAddr)
+		if err != nil {
+			return fmt.Errorf("grpc listen: %w", err)
+		}
+		gs = grpc.NewServer(grpc.UnaryInterceptor(rpc.AdminInterceptor(adminAuth)))
+		rs := rpc.NewServer(d, lm, pe, store)
+		rs.History = history
+		rs.Audit = auditLog
+		rs.Register(gs)
+		go func() {
+			if err := gs.Serve(lis); err != nil {
+				serveErr <- fmt.Errorf("grpc server: %w", err)
+			}
+		}()
+	}
 
-	// 7️⃣ Attach limiter to route
-	lm.SetLimiter("/check", tb)
+	// 9️⃣ Start server and wait for a signal
+	go func() {
+		if err := app.Listen(*addr); err != nil {
+			serveErr <- fmt.Errorf("http server: %w", err)
+		}
+	}()
+	select {
+	case <-ctx.Done():
+		err = nil
+	case err = <-serveErr:
+	}
+	// A second signal kills the process without waiting for the drain
+	stopSignals()
 
-	// 8️⃣ Setup routes
-	http.SetupRouter(app, lm, pe, store)
+	// 🔟 Shut down: report not ready, end /admin/stream subscriptions so
+	// they don't hold the drain open, then let in-flight requests finish.
+	// Deferred calls then close storage, the audit log and the exporter.
+	config.Logger.Info("shutting down", "drain_timeout", *drainTimeout)
+	ready.Drain()
+	if err == nil && *shutdownDelay > 0 {
+		time.Sleep(*shutdownDelay)
+	}
+	decisions.Close()
 
-	// 9️⃣ Start server
-	app.Listen(":8080")
+	drainCtx, cancel := context.WithTimeout(context.Background(), *drainTimeout)
+	defer cancel()
+	errs := []error{err}
+	if serr := app.ShutdownWithContext(drainCtx); serr != nil {
+		errs = append(errs, fmt.Errorf("http drain: %w", serr))
+	}
+	if adminApp != nil {
+		if serr := adminApp.ShutdownWithContext(drainCtx); serr != nil {
+			errs = append(errs, fmt.Errorf("admin drain: %w", serr))
+		}
+	}
+	if gs != nil {
+		stopped := make(chan struct{})
+		go func() {
+			gs.GracefulStop()
+			close(stopped)
+		}()
+		select {
+		case <-stopped:
+		case <-drainCtx.Done():
+			gs.Stop()
+			errs = append(errs, errors.New("grpc drain: timed out"))
+		}
+	}
+	if err := errors.Join(errs...); err != nil {
+		return err
+	}
+	config.Logger.Info("shutdown complete")
+	return nil
 }
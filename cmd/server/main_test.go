@@ -0,0 +1,114 @@
+//go:build unix
+
+package main
+
+import (
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func TestRun_GracefulShutdownOnSignal(t *testing.T) {
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := lis.Addr().String()
+	lis.Close()
+	base := "http://" + addr
+	auditPath := filepath.Join(t.TempDir(), "audit.log")
+
+	done := make(chan error, 1)
+	go func() {
+		done <- run(context.Background(), []string{
+			"-addr", addr,
+			"-grpc-addr", "",
+			"-audit-log", auditPath,
+			"-log-level", "error",
+			"-shutdown-delay", "300ms",
+			"-drain-timeout", "5s",
+		})
+	}()
+
+	// Without keep-alives the client leaves no unused connections behind,
+	// which the server would only count as idle, and close, after 5s.
+	client := &http.Client{Transport: &http.Transport{DisableKeepAlives: true}}
+	status := func(path string) int {
+		resp, err := client.Get(base + path)
+		if err != nil {
+			return 0
+		}
+		resp.Body.Close()
+		return resp.StatusCode
+	}
+	waitFor := func(path string, want int) {
+		t.Helper()
+		deadline := time.Now().Add(5 * time.Second)
+		for status(path) != want {
+			if time.Now().After(deadline) {
+				t.Fatalf("GET %s never returned %d", path, want)
+			}
+			time.Sleep(20 * time.Millisecond)
+		}
+	}
+	waitFor("/readyz", http.StatusOK)
+
+	// An admin change lands in the audit log, which must be flushed on exit
+	resp, err := client.Post(base+"/admin/limiters", "application/json", strings.NewReader(
+		`{"name":"/check","type":"token-bucket","capacity":1,"refill_rate":1,"refill_every":60}`))
+	if err != nil || resp.StatusCode != http.StatusOK {
+		t.Fatalf("create limiter: %v %v", resp, err)
+	}
+	resp.Body.Close()
+
+	// A live stream is in flight when the signal arrives
+	stream, err := client.Get(base + "/admin/stream")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer stream.Body.Close()
+	streamEnded := make(chan struct{})
+	go func() {
+		io.Copy(io.Discard, stream.Body)
+		close(streamEnded)
+	}()
+
+	if err := syscall.Kill(os.Getpid(), syscall.SIGTERM); err != nil {
+		t.Fatal(err)
+	}
+
+	// Not ready while still serving during the shutdown delay
+	waitFor("/readyz", http.StatusServiceUnavailable)
+	if got := status("/healthz"); got != http.StatusOK {
+		t.Fatalf("healthz during shutdown delay: %d", got)
+	}
+
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatalf("run: %v", err)
+		}
+	case <-time.After(10 * time.Second):
+		t.Fatal("run did not return after SIGTERM")
+	}
+
+	select {
+	case <-streamEnded:
+	case <-time.After(time.Second):
+		t.Fatal("stream still open after shutdown")
+	}
+	if got := status("/healthz"); got != 0 {
+		t.Fatalf("server still answering after shutdown: %d", got)
+	}
+	data, err := os.ReadFile(auditPath)
+	if err != nil || !strings.Contains(string(data), `"target":"/check"`) {
+		t.Fatalf("audit log not flushed: %q %v", data, err)
+	}
+}
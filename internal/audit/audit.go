@@ -0,0 +1,174 @@
+package audit
+
+import (
+	"bufio"
+	"encoding/json"
+	"io"
+	"os"
+	"sync"
+	"time"
+)
+
+// Entry records one admin action.
+type Entry struct {
+	Time      time.Time   `json:"time"`
+	Action    string      `json:"action"`
+	Target    string      `json:"target"`
+	Actor     string      `json:"actor,omitempty"`
+	SourceIP  string      `json:"source_ip,omitempty"`
+	RequestID string      `json:"request_id,omitempty"`
+	Before    interface{} `json:"before,omitempty"`
+	After     interface{} `json:"after,omitempty"`
+}
+
+// maxRecent bounds the entries kept in memory for Query by logs that don't
+// write to a file.
+const maxRecent = 1000
+
+// Log writes entries as JSON lines.
+type Log struct {
+	mu     sync.Mutex
+	enc    *json.Encoder
+	file   *os.File // set by Open
+	recent []Entry  // kept for Query when there is no file
+}
+
+// New creates an audit log writing to w. Query only sees the most recent
+// entries recorded by this process.
+func New(w io.Writer) *Log {
+	return &Log{enc: json.NewEncoder(w)}
+}
+
+// Open creates an audit log appending to the file at path, creating it if
+// needed. Query scans the whole file.
+func Open(path string) (*Log, error) {
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0o640)
+	if err != nil {
+		return nil, err
+	}
+	return &Log{enc: json.NewEncoder(f), file: f}, nil
+}
+
+// Record appends an entry, stamping its time if unset.
+func (l *Log) Record(e Entry) error {
+	if e.Time.IsZero() {
+		e.Time = time.Now()
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.file == nil {
+		l.recent = append(l.recent, e)
+		if len(l.recent) > maxRecent {
+			l.recent = append([]Entry(nil), l.recent[len(l.recent)-maxRecent:]...)
+		}
+	}
+	return l.enc.Encode(e)
+}
+
+// Close flushes and closes the file opened by Open.
+func (l *Log) Close() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.file == nil {
+		return nil
+	}
+	if err := l.file.Sync(); err != nil {
+		l.file.Close()
+		return err
+	}
+	return l.file.Close()
+}
+
+// Filter selects entries for Query. Zero fields match everything.
+type Filter struct {
+	Since  time.Time // entries at or after
+	Until  time.Time // entries before
+	Actor  string
+	Action string
+	Limit  int // keep only the most recent Limit matches
+}
+
+// Match reports whether an entry passes the filter.
+func (f Filter) Match(e Entry) bool {
+	switch {
+	case !f.Since.IsZero() && e.Time.Before(f.Since):
+		return false
+	case !f.Until.IsZero() && !e.Time.Before(f.Until):
+		return false
+	case f.Actor != "" && e.Actor != f.Actor:
+		return false
+	case f.Action != "" && e.Action != f.Action:
+		return false
+	}
+	return true
+}
+
+// Query returns the matching entries, oldest first. A file is scanned line
+// by line, keeping only the matches, and only the last Limit of them when
+// Limit is set, so memory stays bounded by the result rather than the log.
+func (l *Log) Query(f Filter) ([]Entry, error) {
+	m := matches{f: f}
+
+	l.mu.Lock()
+	file := l.file
+	if file == nil {
+		for _, e := range l.recent {
+			m.add(e)
+		}
+	}
+	l.mu.Unlock()
+
+	if file != nil {
+		if err := scanFile(file.Name(), m.add); err != nil {
+			return nil, err
+		}
+	}
+	return m.entries(), nil
+}
+
+// matches collects the entries passing a filter. With a Limit it keeps a
+// ring of the most recent Limit matches.
+type matches struct {
+	f    Filter
+	out  []Entry
+	next int // ring position of the oldest kept match, once full
+}
+
+func (m *matches) add(e Entry) {
+	if !m.f.Match(e) {
+		return
+	}
+	if m.f.Limit <= 0 || len(m.out) < m.f.Limit {
+		m.out = append(m.out, e)
+		return
+	}
+	m.out[m.next] = e
+	m.next = (m.next + 1) % m.f.Limit
+}
+
+func (m *matches) entries() []Entry {
+	out := make([]Entry, 0, len(m.out))
+	out = append(out, m.out[m.next:]...)
+	return append(out, m.out[:m.next]...)
+}
+
+// scanFile decodes a JSON-lines audit file, passing each entry to fn and
+// skipping lines that don't parse (e.g. a partial last line).
+func scanFile(path string, fn func(Entry)) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	sc := bufio.NewScanner(f)
+	sc.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	for sc.Scan() {
+		var e Entry
+		if err := json.Unmarshal(sc.Bytes(), &e); err == nil {
+			fn(e)
+		}
+	}
+	return sc.Err()
+}
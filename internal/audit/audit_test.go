@@ -0,0 +1,71 @@
+package audit
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestLog_FileQuery(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.jsonl")
+	l, err := Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t0 := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	l.Record(Entry{Time: t0, Action: "limiter.set", Target: "/a", Actor: "alice", After: map[string]int{"capacity": 5}})
+	l.Record(Entry{Time: t0.Add(time.Hour), Action: "key.reset", Target: "/a/bob", Actor: "bob"})
+	l.Record(Entry{Time: t0.Add(2 * time.Hour), Action: "limiter.delete", Target: "/a", Actor: "alice"})
+	if err := l.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	// Reopening appends rather than truncating.
+	l, err = Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+	l.Record(Entry{Time: t0.Add(3 * time.Hour), Action: "policy.add", Target: "/a", Actor: "carol"})
+
+	got, err := l.Query(Filter{Actor: "alice"})
+	if err != nil || len(got) != 2 || got[0].Action != "limiter.set" || got[1].Action != "limiter.delete" {
+		t.Fatalf("actor filter: %v %+v", err, got)
+	}
+	got, _ = l.Query(Filter{Since: t0.Add(time.Hour), Until: t0.Add(3 * time.Hour)})
+	if len(got) != 2 || got[0].Actor != "bob" {
+		t.Fatalf("time filter: %+v", got)
+	}
+	got, _ = l.Query(Filter{Limit: 1})
+	if len(got) != 1 || got[0].Actor != "carol" {
+		t.Fatalf("limit: %+v", got)
+	}
+}
+
+func TestLog_QueryLimitKeepsLatestMatches(t *testing.T) {
+	l, err := Open(filepath.Join(t.TempDir(), "audit.jsonl"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+
+	t0 := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	for i := 0; i < 50; i++ {
+		actor := "alice"
+		if i%2 == 1 {
+			actor = "bob"
+		}
+		l.Record(Entry{Time: t0.Add(time.Duration(i) * time.Minute), Action: "key.reset", Actor: actor})
+	}
+
+	got, err := l.Query(Filter{Actor: "bob", Limit: 3})
+	if err != nil || len(got) != 3 {
+		t.Fatalf("%v %+v", err, got)
+	}
+	for i, minute := range []int{45, 47, 49} {
+		if want := t0.Add(time.Duration(minute) * time.Minute); !got[i].Time.Equal(want) || got[i].Actor != "bob" {
+			t.Fatalf("entry %d: %+v, want bob at %v", i, got[i], want)
+		}
+	}
+}
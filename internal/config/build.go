@@ -0,0 +1,38 @@
+package config
+
+import (
+	"runtime"
+	"runtime/debug"
+)
+
+// Build details, stamped at link time:
+//
+//	go build -ldflags "-X fibre_rate_limit_service/internal/config.Commit=$(git rev-parse HEAD) \
+//	  -X fibre_rate_limit_service/internal/config.BuildTime=$(date -u +%Y-%m-%dT%H:%M:%SZ)" ./cmd/server
+//
+// Without -X, Commit falls back to the VCS revision Go embeds in the binary.
+var (
+	Commit    string
+	BuildTime string
+)
+
+// BuildInfo describes the running binary.
+type BuildInfo struct {
+	Commit    string `json:"commit,omitempty"`
+	BuildTime string `json:"build_time,omitempty"`
+	GoVersion string `json:"go_version"`
+}
+
+// GetBuildInfo returns the stamped build details, filling in the commit from
+// the binary's VCS information when it wasn't stamped.
+func GetBuildInfo() BuildInfo {
+	b := BuildInfo{Commit: Commit, BuildTime: BuildTime, GoVersion: runtime.Version()}
+	if info, ok := debug.ReadBuildInfo(); ok {
+		for _, s := range info.Settings {
+			if s.Key == "vcs.revision" && b.Commit == "" {
+				b.Commit = s.Value
+			}
+		}
+	}
+	return b
+}
@@ -0,0 +1,248 @@
+// Package configfile loads the service's declarative configuration: storage
+// settings, the client key extractor, limiters and policies. Files are YAML;
+// JSON is accepted too since it is a subset of YAML.
+package configfile
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"fibre_rate_limit_service/internal/config"
+	"fibre_rate_limit_service/internal/decision"
+	"fibre_rate_limit_service/internal/limiters"
+	"fibre_rate_limit_service/internal/policies"
+	"fibre_rate_limit_service/internal/storage"
+)
+
+// Config is a parsed configuration file.
+type Config struct {
+	Storage      Storage
+	KeyExtractor decision.KeyExtractor
+	Limiters     []limiters.Definition
+	Policies     []Policy
+
+	file  string
+	lines map[string]int // item path -> line in file
+}
+
+// Storage configures the shared in-memory store. Durations are in seconds.
+type Storage struct {
+	Shards          int `yaml:"shards" json:"shards"`
+	DefaultTTL      int `yaml:"default_ttl" json:"default_ttl"`
+	CleanupInterval int `yaml:"cleanup_interval" json:"cleanup_interval"`
+}
+
+// DefaultStorage matches the settings the server used before config files.
+func DefaultStorage() Storage {
+	return Storage{Shards: 16, DefaultTTL: 10, CleanupInterval: 5}
+}
+
+// Validate reports settings the store can't run with.
+func (s Storage) Validate() error {
+	switch {
+	case s.Shards <= 0:
+		return fmt.Errorf("%w: shards must be positive", config.ErrInvalidConfig)
+	case s.DefaultTTL < 0:
+		return fmt.Errorf("%w: default_ttl must not be negative", config.ErrInvalidConfig)
+	case s.CleanupInterval <= 0:
+		return fmt.Errorf("%w: cleanup_interval must be positive", config.ErrInvalidConfig)
+	}
+	return nil
+}
+
+// NewStore creates the store described by s.
+func (s Storage) NewStore() *storage.ShardedMap {
+	return storage.NewShardedMap(s.Shards,
+		time.Duration(s.DefaultTTL)*time.Second,
+		time.Duration(s.CleanupInterval)*time.Second)
+}
+
+// Policy is the full list of rules for one route.
+type Policy struct {
+	Route string          `yaml:"route" json:"route"`
+	Rules []policies.Rule `yaml:"rules" json:"rules"`
+}
+
+// Default returns the built-in configuration used when no file is given: a
+// header policy and a token bucket for /check.
+func Default() *Config {
+	return &Config{
+		Storage:      DefaultStorage(),
+		KeyExtractor: decision.DefaultKeyExtractor(),
+		Limiters: []limiters.Definition{{
+			Name: "/check",
+			Type: "token-bucket",
+			Spec: &limiters.TokenBucketSpec{Capacity: 5, RefillRate: 1, RefillEvery: 2, TTL: 30},
+		}},
+		Policies: []Policy{{
+			Route: "/check",
+			Rules: []policies.Rule{{Header: "X-Secret", Value: "123"}},
+		}},
+	}
+}
+
+// Load reads and validates a configuration file.
+func Load(path string) (*Config, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return Parse(path, data)
+}
+
+// Validate checks every section. Errors carry the file position of the
+// offending item when the config was parsed from a file.
+func (c *Config) Validate() error {
+	if err := c.Storage.Validate(); err != nil {
+		return c.errorAt("storage", err)
+	}
+	if err := c.KeyExtractor.Validate(); err != nil {
+		return c.errorAt("key_extractor", err)
+	}
+
+	names := make(map[string]bool, len(c.Limiters))
+	for i, def := range c.Limiters {
+		path := fmt.Sprintf("limiters[%d]", i)
+		if err := validateDefinition(def); err != nil {
+			return c.errorAt(path, err)
+		}
+		if names[def.Name] {
+			return c.errorAt(path, fmt.Errorf("%w: duplicate limiter %q", config.ErrInvalidConfig, def.Name))
+		}
+		names[def.Name] = true
+	}
+
+	routes := make(map[string]bool, len(c.Policies))
+	for i, p := range c.Policies {
+		path := fmt.Sprintf("policies[%d]", i)
+		if p.Route == "" {
+			return c.errorAt(path, fmt.Errorf("%w: route is required", config.ErrInvalidConfig))
+		}
+		if routes[p.Route] {
+			return c.errorAt(path, fmt.Errorf("%w: duplicate policy route %q", config.ErrInvalidConfig, p.Route))
+		}
+		routes[p.Route] = true
+
+		for j, r := range p.Rules {
+			rpath := fmt.Sprintf("%s.rules[%d]", path, j)
+			if r.Header == "" {
+				return c.errorAt(rpath, fmt.Errorf("%w: header is required", config.ErrInvalidConfig))
+			}
+			if err := r.Mode.Validate(); err != nil {
+				return c.errorAt(rpath, err)
+			}
+		}
+	}
+	return nil
+}
+
+// validateDefinition checks a limiter and its overrides the way
+// limiters.Manager.Apply would, without installing anything.
+func validateDefinition(def limiters.Definition) error {
+	if err := def.Validate(); err != nil {
+		return err
+	}
+	seen := make(map[string]bool, len(def.Overrides))
+	for _, o := range def.Overrides {
+		if seen[o.Key] {
+			return fmt.Errorf("%w: duplicate override %q", config.ErrInvalidConfig, o.Key)
+		}
+		seen[o.Key] = true
+		if err := o.Validate(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Apply makes the live limiters, policies and key extractor match the config
+// and returns what changed. The file is authoritative: limiters and policy
+// routes it doesn't list are removed, and each limiter's overrides are
+// replaced by the listed ones. Unchanged limiters are left alone and replaced
+// ones keep their per-key state. The comparison and changes are made in one
+// d.Update, so concurrent applies to the same decider (a reload and an admin
+// apply, say) don't work from stale state, and decisions and admin changes
+// made through it see all or none of them;
+// nothing changes if a limiter fails to build. Storage settings only take
+// effect through NewStore.
+func (c *Config) Apply(lm *limiters.Manager, pe *policies.Evaluator, d *decision.Decider, store *storage.ShardedMap) (Diff, error) {
+	var diff Diff
+	err := d.Update(func() error {
+		var err error
+		diff, err = c.apply(lm, pe, d, store)
+		return err
+	})
+	return diff, err
+}
+
+func (c *Config) apply(lm *limiters.Manager, pe *policies.Evaluator, d *decision.Decider, store *storage.ShardedMap) (Diff, error) {
+	diff := Compare(Current(lm, pe, d), c)
+
+	changed := make(map[string]bool)
+	for _, name := range append(diff.Limiters.Added, diff.Limiters.Modified...) {
+		changed[name] = true
+	}
+	var defs []limiters.Definition
+	for _, def := range c.Limiters {
+		if !changed[def.Name] {
+			continue
+		}
+		if def.Overrides == nil {
+			def.Overrides = []limiters.Override{}
+		}
+		defs = append(defs, def)
+	}
+	if err := lm.ApplyAll(defs, diff.Limiters.Removed, store); err != nil {
+		return Diff{}, err
+	}
+
+	changed = make(map[string]bool)
+	for _, route := range append(diff.Policies.Added, diff.Policies.Modified...) {
+		changed[route] = true
+	}
+	set := make(map[string][]policies.Rule)
+	for _, p := range c.Policies {
+		if changed[p.Route] {
+			set[p.Route] = p.Rules
+		}
+	}
+	pe.ReplaceRoutes(set, diff.Policies.Removed)
+
+	if diff.KeyExtractor {
+		d.SetKeyExtractor(c.KeyExtractor)
+	}
+	return diff, nil
+}
+
+// errorAt wraps err with the position recorded for an item path.
+func (c *Config) errorAt(path string, err error) error {
+	return &Error{File: c.file, Line: c.lines[path], Path: path, Err: err}
+}
+
+// Error is a configuration error with its position in the file. It unwraps to
+// the underlying error, which wraps config.ErrInvalidConfig.
+type Error struct {
+	File string
+	Line int    // 0 when unknown
+	Path string // e.g. "limiters[1]"; empty for whole-file errors
+	Err  error
+}
+
+func (e *Error) Error() string {
+	msg := e.Err.Error()
+	if e.Path != "" {
+		msg = e.Path + ": " + msg
+	}
+	switch {
+	case e.File != "" && e.Line > 0:
+		return fmt.Sprintf("%s:%d: %s", e.File, e.Line, msg)
+	case e.File != "":
+		return e.File + ": " + msg
+	case e.Line > 0:
+		return fmt.Sprintf("line %d: %s", e.Line, msg)
+	}
+	return msg
+}
+
+func (e *Error) Unwrap() error { return e.Err }
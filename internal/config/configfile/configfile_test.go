@@ -0,0 +1,129 @@
+package configfile
+
+import (
+	"errors"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"fibre_rate_limit_service/internal/config"
+	"fibre_rate_limit_service/internal/decision"
+	"fibre_rate_limit_service/internal/limiters"
+	"fibre_rate_limit_service/internal/policies"
+	"fibre_rate_limit_service/internal/storage"
+)
+
+func TestLoad_Example(t *testing.T) {
+	cfg, err := Load("../../../cmd/server/config.example.yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	store := cfg.Storage.NewStore()
+	defer store.Close()
+	lm, pe := limiters.NewManager(), policies.NewEvaluator()
+	d := decision.NewDecider(lm, pe)
+	if _, err := cfg.Apply(lm, pe, d, store); err != nil {
+		t.Fatal(err)
+	}
+
+	if def, ok := lm.Definition("/orders"); !ok || def.Type != "fixed-window" || !def.Mode.Shadow() {
+		t.Fatalf("expected /orders shadow fixed-window limiter, got %+v", def)
+	}
+	if len(lm.Overrides("/check")) != 1 {
+		t.Fatalf("expected one /check override, got %+v", lm.Overrides("/check"))
+	}
+	if rules := pe.Rules("/check"); len(rules) != 1 || rules[0].Header != "X-Secret" {
+		t.Fatalf("unexpected /check rules %+v", rules)
+	}
+	if got := d.ClientID(policies.HeaderFunc(func(string) string { return "" }), "10.0.0.1"); got != "10.0.0.1" {
+		t.Fatalf("expected the ip key source, got %q", got)
+	}
+}
+
+func TestParse_LineNumberedErrors(t *testing.T) {
+	cases := []struct {
+		name, doc, want string
+	}{
+		{"unknown top-level field", "storage:\n  shards: 4\nlimitters: []\n", "cfg.yaml:3: "},
+		{"invalid limiter", "limiters:\n  - name: /a\n    type: token-bucket\n    capacity: 1\n    refill_every: 1\n  - name: /b\n    type: token-bucket\n    capacity: 0\n    refill_every: 1\n", "cfg.yaml:6: limiters[1]: "},
+		{"unknown limiter field", "limiters:\n  - name: /a\n    type: fixed-window\n    limit: 1\n    window: 1\n    capacity: 3\n", "cfg.yaml:6: limiters[0]: "},
+		{"unsupported type", "limiters:\n  - name: /a\n    type: leaky\n", "cfg.yaml:2: limiters[0]: "},
+		{"bad key source", "key_extractor:\n  sources: [cookie]\n", "cfg.yaml:2: key_extractor: "},
+		{"bad limiter mode", "limiters:\n  - name: /a\n    type: fixed-window\n    mode: dry\n    limit: 1\n    window: 1\n", "cfg.yaml:2: limiters[0]: "},
+		{"bad rule mode", "policies:\n  - route: /a\n    rules:\n      - header: X-A\n        mode: dry\n", "cfg.yaml:4: policies[0].rules[0]: "},
+		{"duplicate route", "policies:\n  - route: /a\n  - route: /a\n", "cfg.yaml:3: policies[1]: "},
+		{"syntax", "limiters: [\n", "cfg.yaml: "},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := Parse("cfg.yaml", []byte(tc.doc))
+			if err == nil {
+				t.Fatal("expected an error")
+			}
+			if !errors.Is(err, config.ErrInvalidConfig) {
+				t.Fatalf("expected ErrInvalidConfig, got %v", err)
+			}
+			if !strings.HasPrefix(err.Error(), tc.want) {
+				t.Fatalf("expected prefix %q, got %q", tc.want, err)
+			}
+		})
+	}
+}
+
+func TestParse_JSON(t *testing.T) {
+	cfg, err := Parse("cfg.json", []byte(`{"limiters": [{"name": "/a", "type": "fixed-window", "limit": 3, "window": 1}]}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(cfg.Limiters) != 1 || cfg.Storage != DefaultStorage() {
+		t.Fatalf("unexpected config %+v", cfg)
+	}
+}
+
+func TestLoad_MissingFile(t *testing.T) {
+	if _, err := Load("does-not-exist.yaml"); !errors.Is(err, os.ErrNotExist) {
+		t.Fatalf("expected ErrNotExist, got %v", err)
+	}
+}
+
+func TestApply_LocksPerDecider(t *testing.T) {
+	cfg, err := Parse("cfg.json", []byte(`{"limiters": [{"name": "/a", "type": "fixed-window", "limit": 3, "window": 1}]}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	lm1, pe1 := limiters.NewManager(), policies.NewEvaluator()
+	lm2, pe2 := limiters.NewManager(), policies.NewEvaluator()
+	d1, d2 := decision.NewDecider(lm1, pe1), decision.NewDecider(lm2, pe2)
+	store := storage.NewShardedMap(4, time.Minute, time.Minute)
+	defer store.Close()
+
+	// An update in progress on one decider doesn't hold up applies to another
+	release := make(chan struct{})
+	held := make(chan struct{})
+	go d1.Update(func() error {
+		close(held)
+		<-release
+		return nil
+	})
+	<-held
+	defer close(release)
+
+	applied := make(chan error, 1)
+	go func() {
+		_, err := cfg.Apply(lm2, pe2, d2, store)
+		applied <- err
+	}()
+	select {
+	case err := <-applied:
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Apply blocked on another decider's update")
+	}
+	if _, ok := lm2.GetLimiter("/a"); !ok {
+		t.Fatal("limiter not applied")
+	}
+}
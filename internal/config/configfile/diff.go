@@ -0,0 +1,158 @@
+package configfile
+
+import (
+	"bytes"
+	"encoding/json"
+	"sort"
+
+	"fibre_rate_limit_service/internal/decision"
+	"fibre_rate_limit_service/internal/limiters"
+	"fibre_rate_limit_service/internal/policies"
+)
+
+// Diff lists what changes between two configurations. Limiters are named by
+// limiter name and policies by route.
+type Diff struct {
+	Limiters     Changes `json:"limiters"`
+	Policies     Changes `json:"policies"`
+	KeyExtractor bool    `json:"key_extractor_changed"`
+}
+
+// Changes are the names added, removed and modified in one section, sorted.
+type Changes struct {
+	Added    []string `json:"added"`
+	Removed  []string `json:"removed"`
+	Modified []string `json:"modified"`
+}
+
+// Empty reports whether nothing changes.
+func (c Changes) Empty() bool {
+	return len(c.Added) == 0 && len(c.Removed) == 0 && len(c.Modified) == 0
+}
+
+// Empty reports whether the configurations are equivalent.
+func (d Diff) Empty() bool {
+	return d.Limiters.Empty() && d.Policies.Empty() && !d.KeyExtractor
+}
+
+// Current describes the live limiters, policies and key extractor as a
+// Config. Storage settings can't be read back and are left empty.
+func Current(lm *limiters.Manager, pe *policies.Evaluator, d *decision.Decider) *Config {
+	c := &Config{
+		KeyExtractor: d.KeyExtractor(),
+	}
+
+	names := lm.ListLimiters()
+	sort.Strings(names)
+	for _, name := range names {
+		if def, ok := lm.Definition(name); ok {
+			c.Limiters = append(c.Limiters, def)
+		}
+	}
+	for _, route := range pe.Routes() {
+		c.Policies = append(c.Policies, Policy{Route: route, Rules: pe.Rules(route)})
+	}
+	return c
+}
+
+// Compare returns the changes needed to go from the from config to the to
+// config. Rules without an ID in to match any ID in from.
+func Compare(from, to *Config) Diff {
+	var d Diff
+
+	oldDefs := make(map[string]limiters.Definition, len(from.Limiters))
+	for _, def := range from.Limiters {
+		oldDefs[def.Name] = def
+	}
+	newDefs := make(map[string]bool, len(to.Limiters))
+	for _, def := range to.Limiters {
+		newDefs[def.Name] = true
+		old, ok := oldDefs[def.Name]
+		switch {
+		case !ok:
+			d.Limiters.Added = append(d.Limiters.Added, def.Name)
+		case !sameDefinition(old, def):
+			d.Limiters.Modified = append(d.Limiters.Modified, def.Name)
+		}
+	}
+	for name := range oldDefs {
+		if !newDefs[name] {
+			d.Limiters.Removed = append(d.Limiters.Removed, name)
+		}
+	}
+
+	oldRules := make(map[string][]policies.Rule, len(from.Policies))
+	for _, p := range from.Policies {
+		if len(p.Rules) > 0 {
+			oldRules[p.Route] = p.Rules
+		}
+	}
+	newRoutes := make(map[string]bool, len(to.Policies))
+	for _, p := range to.Policies {
+		if len(p.Rules) == 0 {
+			continue // an empty policy is the same as none
+		}
+		newRoutes[p.Route] = true
+		old, ok := oldRules[p.Route]
+		switch {
+		case !ok:
+			d.Policies.Added = append(d.Policies.Added, p.Route)
+		case !sameRules(old, p.Rules):
+			d.Policies.Modified = append(d.Policies.Modified, p.Route)
+		}
+	}
+	for route := range oldRules {
+		if !newRoutes[route] {
+			d.Policies.Removed = append(d.Policies.Removed, route)
+		}
+	}
+
+	d.KeyExtractor = !sameKeyExtractor(from.KeyExtractor, to.KeyExtractor)
+
+	d.Limiters.sort()
+	d.Policies.sort()
+	return d
+}
+
+func (c *Changes) sort() {
+	for _, names := range []*[]string{&c.Added, &c.Removed, &c.Modified} {
+		if *names == nil {
+			*names = []string{}
+		}
+		sort.Strings(*names)
+	}
+}
+
+// sameDefinition compares definitions by their JSON form, which covers the
+// type, every spec field and the overrides.
+func sameDefinition(a, b limiters.Definition) bool {
+	ra, errA := json.Marshal(a)
+	rb, errB := json.Marshal(b)
+	return errA == nil && errB == nil && bytes.Equal(ra, rb)
+}
+
+func sameRules(old, rules []policies.Rule) bool {
+	if len(old) != len(rules) {
+		return false
+	}
+	for i, r := range rules {
+		o := old[i]
+		if o.Header != r.Header || o.Value != r.Value || o.Mode.Shadow() != r.Mode.Shadow() ||
+			(r.ID != "" && r.ID != o.ID) {
+			return false
+		}
+	}
+	return true
+}
+
+func sameKeyExtractor(a, b decision.KeyExtractor) bool {
+	if a.Fallback != b.Fallback || len(a.Sources) != len(b.Sources) {
+		return false
+	}
+	for i := range a.Sources {
+		if a.Sources[i] != b.Sources[i] {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,103 @@
+package configfile
+
+import (
+	"bytes"
+	"encoding/json"
+
+	"fibre_rate_limit_service/internal/decision"
+	"fibre_rate_limit_service/internal/limiters"
+
+	"gopkg.in/yaml.v3"
+)
+
+// document is the file layout Parse reads.
+type document struct {
+	Storage      *Storage              `json:"storage,omitempty"`
+	KeyExtractor decision.KeyExtractor `json:"key_extractor"`
+	Limiters     []limiters.Definition `json:"limiters"`
+	Policies     []Policy              `json:"policies"`
+}
+
+// MarshalJSON encodes the config in the layout Parse reads. Configs built by
+// Current have no storage section, since those settings can't be read back
+// from a running store.
+func (c *Config) MarshalJSON() ([]byte, error) {
+	doc := document{
+		KeyExtractor: c.KeyExtractor,
+		Limiters:     c.Limiters,
+		Policies:     c.Policies,
+	}
+	if c.Storage != (Storage{}) {
+		doc.Storage = &c.Storage
+	}
+	if doc.Limiters == nil {
+		doc.Limiters = []limiters.Definition{}
+	}
+	if doc.Policies == nil {
+		doc.Policies = []Policy{}
+	}
+	return json.Marshal(doc)
+}
+
+// YAML encodes the config as YAML in the layout Parse reads.
+func (c *Config) YAML() ([]byte, error) {
+	raw, err := json.Marshal(c)
+	if err != nil {
+		return nil, err
+	}
+	// JSON is YAML, so decoding it into a node keeps the key order; clearing
+	// the flow style then prints it in block form.
+	var doc yaml.Node
+	if err := yaml.Unmarshal(raw, &doc); err != nil {
+		return nil, err
+	}
+	blockStyle(&doc)
+	if len(doc.Content) > 0 {
+		nameFirst(doc.Content[0])
+	}
+
+	var buf bytes.Buffer
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+	if err := enc.Encode(&doc); err != nil {
+		return nil, err
+	}
+	if err := enc.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// nameFirst moves "name" and "type" to the front of each limiter, where a
+// person would write them; the JSON form sorts them among the spec fields.
+func nameFirst(root *yaml.Node) {
+	for i := 0; i+1 < len(root.Content); i += 2 {
+		if root.Content[i].Value != "limiters" {
+			continue
+		}
+		for _, item := range root.Content[i+1].Content {
+			var head, rest []*yaml.Node
+			for j := 0; j+1 < len(item.Content); j += 2 {
+				pair := item.Content[j : j+2]
+				if k := pair[0].Value; k == "name" || k == "type" {
+					head = append(head, pair...)
+				} else {
+					rest = append(rest, pair...)
+				}
+			}
+			item.Content = append(head, rest...)
+		}
+	}
+}
+
+func blockStyle(n *yaml.Node) {
+	if n.Kind == yaml.MappingNode || n.Kind == yaml.SequenceNode {
+		n.Style = 0
+	}
+	if n.Kind == yaml.ScalarNode && n.Style == yaml.DoubleQuotedStyle {
+		n.Style = 0 // the encoder re-quotes strings that need it
+	}
+	for _, child := range n.Content {
+		blockStyle(child)
+	}
+}
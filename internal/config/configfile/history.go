@@ -0,0 +1,128 @@
+package configfile
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"fibre_rate_limit_service/internal/decision"
+	"fibre_rate_limit_service/internal/limiters"
+	"fibre_rate_limit_service/internal/policies"
+	"fibre_rate_limit_service/internal/storage"
+)
+
+// ErrVersionNotFound is returned for versions that were never recorded or have
+// been dropped from the history.
+var ErrVersionNotFound = errors.New("config version not found")
+
+// Version is one recorded state of the live configuration.
+type Version struct {
+	Version int       `json:"version"`
+	Author  string    `json:"author"`
+	Action  string    `json:"action"`
+	Time    time.Time `json:"time"`
+	// Diff is the change from the previous version.
+	Diff   Diff    `json:"diff"`
+	Config *Config `json:"-"`
+}
+
+// History records a new Version whenever the live limiters, policies or key
+// extractor change, keeping the most recent ones.
+type History struct {
+	lm *limiters.Manager
+	pe *policies.Evaluator
+	d  *decision.Decider
+
+	mu       sync.Mutex
+	max      int
+	versions []Version // oldest first
+	next     int
+}
+
+// NewHistory records the current state as version 1 and keeps at most max
+// versions (at least one).
+func NewHistory(max int, lm *limiters.Manager, pe *policies.Evaluator, d *decision.Decider) *History {
+	if max < 1 {
+		max = 1
+	}
+	h := &History{lm: lm, pe: pe, d: d, max: max, next: 1}
+	h.versions = []Version{h.newVersion("system", "initial", Diff{}, Current(lm, pe, d))}
+	return h
+}
+
+func (h *History) newVersion(author, action string, diff Diff, cfg *Config) Version {
+	v := Version{
+		Version: h.next,
+		Author:  author,
+		Action:  action,
+		Time:    time.Now(),
+		Diff:    diff,
+		Config:  cfg,
+	}
+	h.next++
+	return v
+}
+
+// Commit records the live state as a new version if it differs from the
+// latest one. It reports the version and whether it is new.
+func (h *History) Commit(author, action string) (Version, bool) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	cur := Current(h.lm, h.pe, h.d)
+	latest := h.versions[len(h.versions)-1]
+	diff := Compare(latest.Config, cur)
+	if diff.Empty() {
+		return latest, false
+	}
+
+	v := h.newVersion(author, action, diff, cur)
+	h.versions = append(h.versions, v)
+	if len(h.versions) > h.max {
+		h.versions = append([]Version(nil), h.versions[len(h.versions)-h.max:]...)
+	}
+	return v, true
+}
+
+// Current describes the live state, as Current does for the history's
+// limiters, policies and decider.
+func (h *History) Current() *Config {
+	return Current(h.lm, h.pe, h.d)
+}
+
+// Versions returns the retained versions, newest first.
+func (h *History) Versions() []Version {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	out := make([]Version, len(h.versions))
+	for i, v := range h.versions {
+		out[len(out)-1-i] = v
+	}
+	return out
+}
+
+// Get returns a retained version.
+func (h *History) Get(version int) (Version, bool) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	for _, v := range h.versions {
+		if v.Version == version {
+			return v, true
+		}
+	}
+	return Version{}, false
+}
+
+// Rollback makes the live state match a retained version in one step and
+// returns the changes. The restored state is not committed; callers record
+// it with Commit like any other change.
+func (h *History) Rollback(version int, store *storage.ShardedMap) (Diff, error) {
+	v, ok := h.Get(version)
+	if !ok {
+		return Diff{}, fmt.Errorf("%w: %d", ErrVersionNotFound, version)
+	}
+	return v.Config.Apply(h.lm, h.pe, h.d, store)
+}
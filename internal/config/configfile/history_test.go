@@ -0,0 +1,33 @@
+package configfile
+
+import (
+	"testing"
+
+	"fibre_rate_limit_service/internal/decision"
+	"fibre_rate_limit_service/internal/limiters"
+	"fibre_rate_limit_service/internal/policies"
+)
+
+func TestHistory_Bounded(t *testing.T) {
+	lm, pe := limiters.NewManager(), policies.NewEvaluator()
+	h := NewHistory(2, lm, pe, decision.NewDecider(lm, pe))
+
+	if _, ok := h.Commit("alice", "noop"); ok {
+		t.Fatal("an unchanged state must not create a version")
+	}
+	pe.AddRule("/a", policies.Rule{Header: "X-A", Value: "1"})
+	h.Commit("alice", "add /a")
+	pe.AddRule("/b", policies.Rule{Header: "X-B", Value: "1"})
+	v, ok := h.Commit("bob", "add /b")
+	if !ok || v.Version != 3 || v.Author != "bob" || len(v.Diff.Policies.Added) != 1 {
+		t.Fatalf("unexpected version %+v", v)
+	}
+
+	versions := h.Versions()
+	if len(versions) != 2 || versions[0].Version != 3 || versions[1].Version != 2 {
+		t.Fatalf("expected versions 3 and 2, got %+v", versions)
+	}
+	if _, err := h.Rollback(1, nil); err == nil {
+		t.Fatal("expected dropped version 1 to be gone")
+	}
+}
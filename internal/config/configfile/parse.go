@@ -0,0 +1,299 @@
+package configfile
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"fibre_rate_limit_service/internal/config"
+	"fibre_rate_limit_service/internal/decision"
+	"fibre_rate_limit_service/internal/limiters"
+	"fibre_rate_limit_service/internal/policies"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Parse decodes and validates a configuration document. name is only used in
+// error messages. Sections that are left out get their defaults (storage and
+// key extractor) or are empty (limiters and policies).
+//
+// Example:
+//
+//	storage:
+//	  shards: 16
+//	  default_ttl: 10
+//	  cleanup_interval: 5
+//	key_extractor:
+//	  sources: ["header:X-Client-ID", "ip"]
+//	  fallback: anonymous
+//	limiters:
+//	  - name: /check
+//	    type: token-bucket
+//	    capacity: 5
+//	    refill_rate: 1
+//	    refill_every: 2
+//	    ttl: 30
+//	    overrides:
+//	      - key: "acme:*"
+//	        capacity: 50
+//	        refill_rate: 10
+//	        refill_every: 1
+//	        ttl: 30
+//	policies:
+//	  - route: /check
+//	    rules:
+//	      - header: X-Secret
+//	        value: "123"
+func Parse(name string, data []byte) (*Config, error) {
+	c := &Config{
+		Storage:      DefaultStorage(),
+		KeyExtractor: decision.DefaultKeyExtractor(),
+		file:         name,
+		lines:        make(map[string]int),
+	}
+
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, c.syntaxError(err)
+	}
+	if doc.Kind == 0 || len(doc.Content) == 0 {
+		return c, nil // empty file
+	}
+	root := doc.Content[0]
+	if root.Kind != yaml.MappingNode {
+		return nil, c.nodeError("", root, "expected a mapping at the top level")
+	}
+
+	fields, err := c.mapping("", root, "storage", "key_extractor", "limiters", "policies")
+	if err != nil {
+		return nil, err
+	}
+
+	if n := fields["storage"]; n != nil {
+		if err := c.decodeStrict("storage", n, &c.Storage, "shards", "default_ttl", "cleanup_interval"); err != nil {
+			return nil, err
+		}
+	}
+	if n := fields["key_extractor"]; n != nil {
+		if err := c.decodeStrict("key_extractor", n, &c.KeyExtractor, "sources", "fallback"); err != nil {
+			return nil, err
+		}
+	}
+	if n := fields["limiters"]; n != nil {
+		if err := c.parseLimiters(n); err != nil {
+			return nil, err
+		}
+	}
+	if n := fields["policies"]; n != nil {
+		if err := c.parsePolicies(n); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := c.Validate(); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+func (c *Config) parseLimiters(n *yaml.Node) error {
+	if n.Kind != yaml.SequenceNode {
+		return c.nodeError("limiters", n, "expected a list")
+	}
+	for i, item := range n.Content {
+		path := fmt.Sprintf("limiters[%d]", i)
+		c.lines[path] = item.Line
+
+		def, err := c.parseDefinition(path, item)
+		if err != nil {
+			return err
+		}
+		c.Limiters = append(c.Limiters, def)
+	}
+	return nil
+}
+
+// parseDefinition decodes a limiter through its JSON form, which is what
+// limiters.Definition understands, then rejects keys its type doesn't use.
+func (c *Config) parseDefinition(path string, n *yaml.Node) (limiters.Definition, error) {
+	var def limiters.Definition
+	if n.Kind != yaml.MappingNode {
+		return def, c.nodeError(path, n, "expected a mapping")
+	}
+	raw, err := nodeJSON(n)
+	if err != nil {
+		return def, c.nodeError(path, n, err.Error())
+	}
+	if err := json.Unmarshal(raw, &def); err != nil {
+		return def, c.wrap(path, n, err)
+	}
+
+	allowed := append(specKeys(def.Spec), "name", "type", "mode", "overrides")
+	fields, err := c.mapping(path, n, allowed...)
+	if err != nil {
+		return def, err
+	}
+
+	if on := fields["overrides"]; on != nil && on.Kind == yaml.SequenceNode {
+		for j, item := range on.Content {
+			opath := fmt.Sprintf("%s.overrides[%d]", path, j)
+			c.lines[opath] = item.Line
+			if j < len(def.Overrides) {
+				if _, err := c.mapping(opath, item, append(specKeys(def.Overrides[j].Spec), "key")...); err != nil {
+					return def, err
+				}
+			}
+		}
+	}
+	return def, nil
+}
+
+func (c *Config) parsePolicies(n *yaml.Node) error {
+	if n.Kind != yaml.SequenceNode {
+		return c.nodeError("policies", n, "expected a list")
+	}
+	for i, item := range n.Content {
+		path := fmt.Sprintf("policies[%d]", i)
+		c.lines[path] = item.Line
+
+		fields, err := c.mapping(path, item, "route", "rules")
+		if err != nil {
+			return err
+		}
+		var p Policy
+		if n := fields["route"]; n != nil {
+			if err := n.Decode(&p.Route); err != nil {
+				return c.wrap(path+".route", n, err)
+			}
+		}
+		if rules := fields["rules"]; rules != nil {
+			if rules.Kind != yaml.SequenceNode {
+				return c.nodeError(path+".rules", rules, "expected a list")
+			}
+			for j, rn := range rules.Content {
+				rpath := fmt.Sprintf("%s.rules[%d]", path, j)
+				c.lines[rpath] = rn.Line
+				// yaml's default field names match Rule's json tags
+				var r policies.Rule
+				if err := c.decodeStrict(rpath, rn, &r, "id", "header", "value", "mode"); err != nil {
+					return err
+				}
+				p.Rules = append(p.Rules, r)
+			}
+		}
+		c.Policies = append(c.Policies, p)
+	}
+	return nil
+}
+
+// mapping returns a mapping node's values by key, rejecting keys not in
+// allowed.
+func (c *Config) mapping(path string, n *yaml.Node, allowed ...string) (map[string]*yaml.Node, error) {
+	if n.Kind != yaml.MappingNode {
+		return nil, c.nodeError(path, n, "expected a mapping")
+	}
+	ok := make(map[string]bool, len(allowed))
+	for _, k := range allowed {
+		ok[k] = true
+	}
+
+	fields := make(map[string]*yaml.Node, len(n.Content)/2)
+	for i := 0; i+1 < len(n.Content); i += 2 {
+		key, val := n.Content[i], n.Content[i+1]
+		if !ok[key.Value] {
+			sort.Strings(allowed)
+			return nil, c.nodeError(path, key, fmt.Sprintf("unknown field %q (want one of %s)", key.Value, strings.Join(allowed, ", ")))
+		}
+		if _, dup := fields[key.Value]; dup {
+			return nil, c.nodeError(path, key, fmt.Sprintf("field %q set twice", key.Value))
+		}
+		fields[key.Value] = val
+	}
+	return fields, nil
+}
+
+// decodeStrict checks a mapping's keys and decodes it into v.
+func (c *Config) decodeStrict(path string, n *yaml.Node, v interface{}, allowed ...string) error {
+	c.lines[path] = n.Line
+	if _, err := c.mapping(path, n, allowed...); err != nil {
+		return err
+	}
+	if err := n.Decode(v); err != nil {
+		return c.wrap(path, n, err)
+	}
+	return nil
+}
+
+func (c *Config) nodeError(path string, n *yaml.Node, msg string) error {
+	return &Error{File: c.file, Line: n.Line, Path: path, Err: fmt.Errorf("%w: %s", config.ErrInvalidConfig, msg)}
+}
+
+// wrap attaches a node's position to a decoding error, marking it invalid
+// config if it isn't already.
+func (c *Config) wrap(path string, n *yaml.Node, err error) error {
+	if !errors.Is(err, config.ErrInvalidConfig) {
+		err = fmt.Errorf("%w: %v", config.ErrInvalidConfig, err)
+	}
+	return &Error{File: c.file, Line: n.Line, Path: path, Err: err}
+}
+
+// syntaxError reports a YAML syntax error, whose message already includes
+// the line.
+func (c *Config) syntaxError(err error) error {
+	return &Error{File: c.file, Err: fmt.Errorf("%w: %v", config.ErrInvalidConfig, err)}
+}
+
+// nodeJSON converts a YAML node to JSON.
+func nodeJSON(n *yaml.Node) ([]byte, error) {
+	var v interface{}
+	if err := n.Decode(&v); err != nil {
+		return nil, err
+	}
+	return json.Marshal(jsonCompatible(v))
+}
+
+// jsonCompatible converts the map[interface{}]interface{} values yaml can
+// produce for non-string keys into maps encoding/json accepts.
+func jsonCompatible(v interface{}) interface{} {
+	switch t := v.(type) {
+	case map[string]interface{}:
+		for k, e := range t {
+			t[k] = jsonCompatible(e)
+		}
+		return t
+	case map[interface{}]interface{}:
+		out := make(map[string]interface{}, len(t))
+		for k, e := range t {
+			out[fmt.Sprint(k)] = jsonCompatible(e)
+		}
+		return out
+	case []interface{}:
+		for i, e := range t {
+			t[i] = jsonCompatible(e)
+		}
+		return t
+	}
+	return v
+}
+
+// specKeys returns the field names a spec uses in its flat JSON form.
+func specKeys(spec limiters.Spec) []string {
+	if spec == nil {
+		return nil
+	}
+	raw, err := json.Marshal(spec)
+	if err != nil {
+		return nil
+	}
+	var m map[string]interface{}
+	if err := json.Unmarshal(raw, &m); err != nil {
+		return nil
+	}
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	return keys
+}
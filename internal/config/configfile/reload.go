@@ -0,0 +1,131 @@
+package configfile
+
+import (
+	"os"
+	"sync"
+	"time"
+
+	"fibre_rate_limit_service/internal/config"
+	"fibre_rate_limit_service/internal/decision"
+	"fibre_rate_limit_service/internal/health"
+	"fibre_rate_limit_service/internal/limiters"
+	"fibre_rate_limit_service/internal/policies"
+	"fibre_rate_limit_service/internal/storage"
+)
+
+// Reloader re-reads a config file and applies what changed. A file that
+// fails to load or validate is ignored and the running config stays live.
+type Reloader struct {
+	path  string
+	lm    *limiters.Manager
+	pe    *policies.Evaluator
+	d     *decision.Decider
+	store *storage.ShardedMap
+
+	// History, if set, records a version for each reload that changes
+	// something.
+	History *History
+	// Readiness, if set, reports not ready while a reload is applied.
+	Readiness *health.Readiness
+
+	mu      sync.Mutex
+	current *Config
+	modTime time.Time
+}
+
+// NewReloader watches path, whose contents current was loaded from and has
+// already been applied.
+func NewReloader(path string, current *Config, lm *limiters.Manager, pe *policies.Evaluator, d *decision.Decider, store *storage.ShardedMap) *Reloader {
+	r := &Reloader{
+		path:    path,
+		lm:      lm,
+		pe:      pe,
+		d:       d,
+		store:   store,
+		current: current,
+	}
+	if fi, err := os.Stat(path); err == nil {
+		r.modTime = fi.ModTime()
+	}
+	return r
+}
+
+// Current returns the last config that was applied successfully.
+func (r *Reloader) Current() *Config {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.current
+}
+
+// Reload loads the file and applies it. On error nothing is changed and the
+// previous config stays current.
+func (r *Reloader) Reload() (Diff, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.Readiness != nil {
+		defer r.Readiness.Reloading()()
+	}
+
+	if fi, err := os.Stat(r.path); err == nil {
+		r.modTime = fi.ModTime()
+	}
+
+	next, err := Load(r.path)
+	if err != nil {
+		return Diff{}, err
+	}
+	if next.Storage != r.current.Storage {
+		config.Logger.Warn("config reload: storage settings changed; restart to apply them", "file", r.path)
+	}
+
+	// Apply changes nothing when it fails, so the previous config stays live.
+	diff, err := next.Apply(r.lm, r.pe, r.d, r.store)
+	if err != nil {
+		return Diff{}, err
+	}
+	r.current = next
+	if r.History != nil && !diff.Empty() {
+		r.History.Commit("config-file", "reload "+r.path)
+	}
+	return diff, nil
+}
+
+// Watch polls the file's modification time every interval and reloads it
+// when it changes, until stop is closed. Results are logged.
+func (r *Reloader) Watch(interval time.Duration, stop <-chan struct{}) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+			fi, err := os.Stat(r.path)
+			if err != nil {
+				continue
+			}
+			r.mu.Lock()
+			changed := !fi.ModTime().Equal(r.modTime)
+			r.mu.Unlock()
+			if changed {
+				r.ReloadAndLog("file changed")
+			}
+		}
+	}
+}
+
+// ReloadAndLog reloads the file and logs the outcome with the reason given.
+func (r *Reloader) ReloadAndLog(reason string) {
+	diff, err := r.Reload()
+	if err != nil {
+		config.Logger.Error("config reload: keeping previous config", "reason", reason, "file", r.path, "err", err)
+		return
+	}
+	if diff.Empty() {
+		config.Logger.Info("config reload: no changes", "reason", reason, "file", r.path)
+		return
+	}
+	config.Logger.Info("config reload: applied", "reason", reason, "file", r.path,
+		"limiters", diff.Limiters, "policies", diff.Policies, "key_extractor_changed", diff.KeyExtractor)
+}
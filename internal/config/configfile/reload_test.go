@@ -0,0 +1,96 @@
+package configfile
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"fibre_rate_limit_service/internal/decision"
+	"fibre_rate_limit_service/internal/limiters"
+	"fibre_rate_limit_service/internal/policies"
+	"fibre_rate_limit_service/internal/storage"
+)
+
+func TestReloader_AppliesDiffAndKeepsState(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	write := func(doc string) {
+		t.Helper()
+		if err := os.WriteFile(path, []byte(doc), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write(`
+limiters:
+  - {name: /a, type: token-bucket, capacity: 3, refill_rate: 1, refill_every: 60, ttl: 60}
+  - {name: /b, type: fixed-window, limit: 1, window: 60}
+policies:
+  - route: /a
+    rules: [{header: X-Secret, value: "1"}]
+`)
+
+	store := storage.NewShardedMap(4, time.Minute, time.Minute)
+	defer store.Close()
+	lm, pe := limiters.NewManager(), policies.NewEvaluator()
+	d := decision.NewDecider(lm, pe)
+
+	cfg, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := cfg.Apply(lm, pe, d, store); err != nil {
+		t.Fatal(err)
+	}
+	l, _, _ := lm.Resolve("/a", "alice")
+	l.Check("alice")
+	ruleID := pe.Rules("/a")[0].ID
+
+	// Raise /a's capacity, drop /b, add /c and change the key extractor.
+	write(`
+key_extractor: {sources: [ip]}
+limiters:
+  - {name: /a, type: token-bucket, capacity: 10, refill_rate: 1, refill_every: 60, ttl: 60}
+  - {name: /c, type: fixed-window, limit: 1, window: 60}
+policies:
+  - route: /a
+    rules: [{header: X-Secret, value: "1"}]
+`)
+	r := NewReloader(path, cfg, lm, pe, d, store)
+	diff, err := r.Reload()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := Diff{
+		Limiters:     Changes{Added: []string{"/c"}, Removed: []string{"/b"}, Modified: []string{"/a"}},
+		Policies:     Changes{Added: []string{}, Removed: []string{}, Modified: []string{}},
+		KeyExtractor: true,
+	}
+	if !reflect.DeepEqual(diff, want) {
+		t.Fatalf("diff = %+v, want %+v", diff, want)
+	}
+
+	// alice's bucket is kept: 2 tokens left from before, one more used now.
+	l, _, _ = lm.Resolve("/a", "alice")
+	if res := l.Check("alice"); res.Limit != 10 || res.Remaining != 1 {
+		t.Fatalf("expected kept state under the new limit, got %+v", res)
+	}
+	if _, ok := lm.GetLimiter("/b"); ok {
+		t.Fatal("expected /b to be removed")
+	}
+	if got := pe.Rules("/a")[0].ID; got != ruleID {
+		t.Fatalf("unchanged rule got a new ID: %s -> %s", ruleID, got)
+	}
+
+	// An invalid file is rejected and the previous config stays live.
+	write(`
+limiters:
+  - {name: /a, type: token-bucket, capacity: 0}
+`)
+	if _, err := r.Reload(); err == nil {
+		t.Fatal("expected an invalid config to fail")
+	}
+	if _, ok := lm.GetLimiter("/c"); !ok || r.Current() == cfg {
+		t.Fatal("expected the previously reloaded config to stay live")
+	}
+}
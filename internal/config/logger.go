@@ -1,5 +1,30 @@
 package config
 
-import "log"
+import (
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"strings"
+)
 
-var Logger = log.Default()
+// LogLevel is the minimum level Logger writes. It can be changed at any
+// time, e.g. from a -log-level flag.
+var LogLevel = new(slog.LevelVar)
+
+// Logger writes JSON lines to stderr.
+var Logger = NewLogger(os.Stderr)
+
+// NewLogger creates a JSON logger writing to w at LogLevel.
+func NewLogger(w io.Writer) *slog.Logger {
+	return slog.New(slog.NewJSONHandler(w, &slog.HandlerOptions{Level: LogLevel}))
+}
+
+// ParseLogLevel parses "debug", "info", "warn" or "error".
+func ParseLogLevel(s string) (slog.Level, error) {
+	var l slog.Level
+	if err := l.UnmarshalText([]byte(strings.TrimSpace(s))); err != nil {
+		return 0, fmt.Errorf("%w: log level must be debug, info, warn or error, not %q", ErrInvalidConfig, s)
+	}
+	return l, nil
+}
@@ -1,14 +1,19 @@
 package config
 
+// Version is the service version, set at link time with
+// -ldflags "-X fibre_rate_limit_service/internal/config.Version=1.2.3".
+var Version = "1.0.0"
+
 // Metadata holds static service information.
 type Metadata struct {
 	ServiceName string
 	Version     string
 }
 
+// GetMetadata returns the service name and Version.
 func GetMetadata() Metadata {
 	return Metadata{
 		ServiceName: "Fibre_Rate_Limit_Service",
-		Version:     "1.0.0",
+		Version:     Version,
 	}
 }
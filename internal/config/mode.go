@@ -0,0 +1,28 @@
+package config
+
+import "fmt"
+
+// Mode says whether a limiter or policy rule is enforced, or only evaluated
+// so its would-be decisions can be observed before it is enforced.
+type Mode string
+
+const (
+	// ModeEnforce rejects requests; it is the default for an empty Mode.
+	ModeEnforce Mode = "enforce"
+	// ModeShadow records what would have been rejected but allows it.
+	ModeShadow Mode = "shadow"
+)
+
+// Shadow reports whether m is ModeShadow.
+func (m Mode) Shadow() bool {
+	return m == ModeShadow
+}
+
+// Validate accepts "", "enforce" and "shadow".
+func (m Mode) Validate() error {
+	switch m {
+	case "", ModeEnforce, ModeShadow:
+		return nil
+	}
+	return fmt.Errorf("%w: mode must be enforce or shadow, not %q", ErrInvalidConfig, string(m))
+}
@@ -0,0 +1,233 @@
+package decision
+
+import (
+	"context"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"fibre_rate_limit_service/internal/config"
+	"fibre_rate_limit_service/internal/limiters"
+	"fibre_rate_limit_service/internal/policies"
+)
+
+// Outcome classifies the result of a decision.
+type Outcome int
+
+const (
+	// Allowed means the request passed policies and its limiter.
+	Allowed Outcome = iota
+	// PolicyDenied means a policy rule rejected the request.
+	PolicyDenied
+	// RateLimited means the route's limiter rejected the request.
+	RateLimited
+)
+
+// String returns "allowed", "policy_denied" or "rate_limited".
+func (o Outcome) String() string {
+	switch o {
+	case Allowed:
+		return "allowed"
+	case PolicyDenied:
+		return "policy_denied"
+	case RateLimited:
+		return "rate_limited"
+	}
+	return "outcome(" + strconv.Itoa(int(o)) + ")"
+}
+
+// Request describes the request being checked, independent of transport.
+type Request struct {
+	ID       string // request ID for logs, e.g. from X-Request-ID; optional
+	ClientID string
+	Route    string
+	Method   string // the original request's method, for logs; optional
+	Headers  policies.Headers
+}
+
+// Decision is the combined outcome of policy evaluation and rate limiting.
+type Decision struct {
+	Outcome Outcome
+	Reason  string
+
+	// Policy is the policy evaluation result.
+	Policy policies.Result
+
+	// Limited is true when a limiter was configured for the route, in which
+	// case Limiter names it and Result holds its answer.
+	Limited bool
+	Limiter string
+	Result  limiters.Result
+	// Mode is the limiter's mode. A shadow limiter's Result may deny the
+	// request while Outcome is still Allowed.
+	Mode config.Mode
+
+	// ShadowOutcome is what enforcing the shadow-mode rules and limiter
+	// would have decided, and ShadowReason why. It is Allowed unless one of
+	// them would have rejected an otherwise allowed request.
+	ShadowOutcome Outcome
+	ShadowReason  string
+}
+
+// Allowed reports whether the request may proceed.
+func (d Decision) Allowed() bool {
+	return d.Outcome == Allowed
+}
+
+// Shadowed reports whether a shadow-mode rule or limiter would have rejected
+// the request.
+func (d Decision) Shadowed() bool {
+	return d.ShadowOutcome != Allowed
+}
+
+// WriteHeaders emits the X-RateLimit-* headers (and Retry-After when
+// throttled) describing the limiter's answer through set.
+func (d Decision) WriteHeaders(set func(name, value string)) {
+	if !d.Limited {
+		return
+	}
+
+	set("X-RateLimit-Limit", strconv.Itoa(d.Result.Limit))
+	set("X-RateLimit-Remaining", strconv.Itoa(d.Result.Remaining))
+	set("X-RateLimit-Reset", strconv.FormatInt(d.Result.ResetAt.Unix(), 10))
+
+	if d.Outcome == RateLimited {
+		retry := int(time.Until(d.Result.ResetAt).Seconds() + 0.999)
+		if retry < 0 {
+			retry = 0
+		}
+		set("Retry-After", strconv.Itoa(retry))
+	}
+}
+
+// Decider evaluates policies and then the route's limiter.
+type Decider struct {
+	// mu is held for reading by each decision and for writing by Update, so
+	// a decision sees the limiters and policies from before or after an
+	// update, never a mix.
+	mu        sync.RWMutex
+	lm        *limiters.Manager
+	pe        *policies.Evaluator
+	keys      atomic.Pointer[KeyExtractor]
+	observers atomic.Pointer[[]Observer]
+}
+
+// Observer is called after every decision, e.g. to count outcomes. It runs
+// on the request path and must not block. The request's strings may be
+// reused after it returns, so observers copy any they keep.
+type Observer func(req Request, d Decision)
+
+// NewDecider creates a decider backed by the given limiters and policies,
+// identifying clients with DefaultKeyExtractor.
+func NewDecider(lm *limiters.Manager, pe *policies.Evaluator) *Decider {
+	d := &Decider{
+		lm: lm,
+		pe: pe,
+	}
+	d.SetKeyExtractor(DefaultKeyExtractor())
+	return d
+}
+
+// SetKeyExtractor changes how ClientID identifies callers. It is safe to call
+// while requests are being decided.
+func (d *Decider) SetKeyExtractor(k KeyExtractor) {
+	d.keys.Store(&k)
+}
+
+// KeyExtractor returns the extractor ClientID uses.
+func (d *Decider) KeyExtractor() KeyExtractor {
+	return *d.keys.Load()
+}
+
+// ClientID extracts the client key from request headers and caller IP.
+func (d *Decider) ClientID(h policies.Headers, ip string) string {
+	return d.keys.Load().Extract(h, ip)
+}
+
+// Update runs fn with no decision in progress and no other Update running,
+// and returns its error. Changes fn makes to the limiters, policies and key
+// extractor are published together. fn must not call Decide or Update.
+func (d *Decider) Update(fn func() error) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return fn()
+}
+
+// Observe registers an observer for every later decision.
+func (d *Decider) Observe(o Observer) {
+	for {
+		old := d.observers.Load()
+		var next []Observer
+		if old != nil {
+			next = append(next, *old...)
+		}
+		next = append(next, o)
+		if d.observers.CompareAndSwap(old, &next) {
+			return
+		}
+	}
+}
+
+// Decide runs policies first and only consumes from the limiter when they
+// pass, then notifies observers.
+func (d *Decider) Decide(req Request) Decision {
+	return d.DecideContext(context.Background(), req)
+}
+
+// DecideContext is Decide, tracing the decision when ctx carries a recording
+// span: policy evaluation and the limiter check become child spans, and the
+// span gets the decision, limiter and remaining attributes.
+func (d *Decider) DecideContext(ctx context.Context, req Request) Decision {
+	d.mu.RLock()
+	dec := d.decide(ctx, req)
+	d.mu.RUnlock()
+	annotate(ctx, dec)
+	if obs := d.observers.Load(); obs != nil {
+		for _, o := range *obs {
+			o(req, dec)
+		}
+	}
+	return dec
+}
+
+func (d *Decider) decide(ctx context.Context, req Request) Decision {
+	// Step 1: Evaluate policy
+	end := startSpan(ctx, "policy.evaluate", req.Route)
+	policyResult := d.pe.Evaluate(req.ClientID, req.Route, req.Headers)
+	end(policyAttrs(policyResult)...)
+	if !policyResult.Allowed {
+		return Decision{
+			Outcome: PolicyDenied,
+			Reason:  policyResult.Reason,
+			Policy:  policyResult,
+		}
+	}
+
+	dec := Decision{Outcome: Allowed, Policy: policyResult}
+	if policyResult.ShadowDenied {
+		dec.ShadowOutcome, dec.ShadowReason = PolicyDenied, policyResult.ShadowReason
+	}
+
+	// Step 2: Apply limiter
+	l, mode, ok := d.lm.Resolve(req.Route, req.ClientID)
+	if !ok {
+		// If no limiter defined, allow by default
+		dec.Reason = "no limiter configured for this route"
+		return dec
+	}
+
+	end = startSpan(ctx, "limiter.check", req.Route)
+	res := l.Check(req.ClientID)
+	dec.Limited, dec.Limiter, dec.Result, dec.Mode = true, req.Route, res, mode
+	end(limiterAttrs(dec)...)
+	if !res.Allowed {
+		switch {
+		case !dec.Mode.Shadow():
+			dec.Outcome, dec.Reason = RateLimited, "rate limit exceeded"
+		case !dec.Shadowed():
+			dec.ShadowOutcome, dec.ShadowReason = RateLimited, "rate limit exceeded"
+		}
+	}
+	return dec
+}
@@ -0,0 +1,177 @@
+package decision
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"fibre_rate_limit_service/internal/config"
+	"fibre_rate_limit_service/internal/limiters"
+	"fibre_rate_limit_service/internal/policies"
+	"fibre_rate_limit_service/internal/storage"
+)
+
+func TestDecider_PolicyThenLimiter(t *testing.T) {
+	store := storage.NewShardedMap(4, time.Minute, time.Minute)
+	defer store.Close()
+
+	lm := limiters.NewManager()
+	lm.SetLimiter("/orders", limiters.NewTokenBucket(limiters.TokenBucketConfig{
+		Name:        "/orders",
+		Capacity:    1,
+		RefillRate:  1,
+		RefillEvery: time.Hour,
+	}, store))
+
+	pe := policies.NewEvaluator()
+	pe.AddRule("/orders", policies.Rule{Header: "X-Secret", Value: "123"})
+
+	d := NewDecider(lm, pe)
+	h := http.Header{}
+
+	res := d.Decide(Request{ClientID: "c1", Route: "/orders", Headers: h})
+	if res.Outcome != PolicyDenied {
+		t.Fatalf("expected policy denial without header, got %v", res.Outcome)
+	}
+
+	h.Set("X-Secret", "123")
+	res = d.Decide(Request{ClientID: "c1", Route: "/orders", Headers: h})
+	if !res.Allowed() || !res.Limited || res.Result.Limit != 1 {
+		t.Fatalf("expected first request allowed by limiter, got %+v", res)
+	}
+
+	res = d.Decide(Request{ClientID: "c1", Route: "/orders", Headers: h})
+	if res.Outcome != RateLimited {
+		t.Fatalf("expected second request rate limited, got %+v", res)
+	}
+
+	res = d.Decide(Request{ClientID: "c1", Route: "/other", Headers: h})
+	if !res.Allowed() || res.Limited {
+		t.Fatalf("expected unlimited route to be allowed, got %+v", res)
+	}
+}
+
+func TestDecider_UpdateIsAtomic(t *testing.T) {
+	store := storage.NewShardedMap(4, time.Minute, time.Minute)
+	defer store.Close()
+	lm := limiters.NewManager()
+	pe := policies.NewEvaluator()
+	d := NewDecider(lm, pe)
+
+	// A policy and a limiter added in one update are seen together: every
+	// decision is either unrestricted or both policy checked and limited.
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		d.Update(func() error {
+			pe.AddRule("/orders", policies.Rule{Header: "X-Secret", Value: "123"})
+			time.Sleep(10 * time.Millisecond)
+			lm.SetLimiter("/orders", limiters.NewTokenBucket(limiters.TokenBucketConfig{
+				Name:        "/orders",
+				Capacity:    1000,
+				RefillRate:  1,
+				RefillEvery: time.Hour,
+			}, store))
+			return nil
+		})
+	}()
+	h := http.Header{"X-Secret": {"123"}}
+	for {
+		select {
+		case <-done:
+			return
+		default:
+		}
+		res := d.Decide(Request{ClientID: "c1", Route: "/orders", Headers: h})
+		if res.Policy.HasRules != res.Limited {
+			t.Fatalf("decision saw half an update: %+v", res)
+		}
+	}
+}
+
+func TestDecider_ShadowMode(t *testing.T) {
+	store := storage.NewShardedMap(4, time.Minute, time.Minute)
+	defer store.Close()
+
+	lm := limiters.NewManager()
+	if err := lm.Apply(limiters.Definition{
+		Name: "/orders",
+		Type: "token-bucket",
+		Mode: config.ModeShadow,
+		Spec: &limiters.TokenBucketSpec{Capacity: 1, RefillRate: 1, RefillEvery: 3600},
+	}, store); err != nil {
+		t.Fatal(err)
+	}
+
+	pe := policies.NewEvaluator()
+	pe.AddRule("/orders", policies.Rule{Header: "X-Trial", Value: "yes", Mode: config.ModeShadow})
+	d := NewDecider(lm, pe)
+
+	res := d.Decide(Request{ClientID: "c1", Route: "/orders", Headers: http.Header{}})
+	if !res.Allowed() || res.ShadowOutcome != PolicyDenied || res.ShadowReason == "" {
+		t.Fatalf("expected shadow policy denial to be allowed and recorded, got %+v", res)
+	}
+
+	// The request still went through, so it used c1's only token
+	h := http.Header{}
+	h.Set("X-Trial", "yes")
+	res = d.Decide(Request{ClientID: "c2", Route: "/orders", Headers: h})
+	if !res.Allowed() || res.Shadowed() {
+		t.Fatalf("expected first request allowed without shadow denial, got %+v", res)
+	}
+
+	res = d.Decide(Request{ClientID: "c2", Route: "/orders", Headers: h})
+	if !res.Allowed() || res.ShadowOutcome != RateLimited || res.Result.Allowed || !res.Mode.Shadow() {
+		t.Fatalf("expected shadow limiter to allow an exhausted key, got %+v", res)
+	}
+
+	// Switching to enforce mode starts rejecting
+	def, _ := lm.Definition("/orders")
+	def.Mode = config.ModeEnforce
+	if err := lm.Apply(def, store); err != nil {
+		t.Fatal(err)
+	}
+	if res = d.Decide(Request{ClientID: "c2", Route: "/orders", Headers: h}); res.Outcome != RateLimited {
+		t.Fatalf("expected enforced limiter to reject, got %+v", res)
+	}
+}
+
+func TestLogObserver(t *testing.T) {
+	var buf bytes.Buffer
+	var level slog.LevelVar
+	l := slog.New(slog.NewJSONHandler(&buf, &slog.HandlerOptions{Level: &level}))
+	log := LogObserver(l)
+
+	req := Request{ID: "req-1", ClientID: "c1", Route: "/orders", Method: "POST"}
+	limited := Decision{Outcome: RateLimited, Reason: "rate limit exceeded", Limited: true, Limiter: "/orders"}
+
+	log(req, Decision{Outcome: Allowed})
+	if buf.Len() != 0 {
+		t.Fatalf("allowed decision logged at info: %s", buf.String())
+	}
+
+	log(req, limited)
+	var line map[string]interface{}
+	if err := json.Unmarshal(buf.Bytes(), &line); err != nil {
+		t.Fatal(err)
+	}
+	for k, want := range map[string]interface{}{
+		"msg": "decision", "request_id": "req-1", "route": "/orders", "key": "c1", "method": "POST",
+		"limiter": "/orders", "outcome": "rate_limited", "remaining": float64(0),
+	} {
+		if line[k] != want {
+			t.Errorf("%s = %v, want %v", k, line[k], want)
+		}
+	}
+
+	buf.Reset()
+	level.Set(slog.LevelDebug)
+	log(req, Decision{Outcome: Allowed})
+	if !strings.Contains(buf.String(), `"outcome":"allowed"`) {
+		t.Errorf("allowed decision not logged at debug: %s", buf.String())
+	}
+}
@@ -0,0 +1,59 @@
+package decision
+
+import (
+	"fmt"
+	"strings"
+
+	"fibre_rate_limit_service/internal/config"
+	"fibre_rate_limit_service/internal/policies"
+)
+
+// KeyExtractor derives the client key a request is limited by. Sources are
+// tried in order and the first non-empty value wins:
+//
+//	header:<Name>  the value of a request header
+//	ip             the caller's IP address
+//
+// Fallback is used when no source yields a value.
+type KeyExtractor struct {
+	Sources  []string `yaml:"sources" json:"sources"`
+	Fallback string   `yaml:"fallback" json:"fallback"`
+}
+
+// DefaultKeyExtractor reads X-Client-ID and falls back to "anonymous".
+func DefaultKeyExtractor() KeyExtractor {
+	return KeyExtractor{
+		Sources:  []string{"header:X-Client-ID"},
+		Fallback: "anonymous",
+	}
+}
+
+// Validate reports unknown sources.
+func (k KeyExtractor) Validate() error {
+	for _, src := range k.Sources {
+		if src == "ip" {
+			continue
+		}
+		if name, ok := strings.CutPrefix(src, "header:"); ok && name != "" {
+			continue
+		}
+		return fmt.Errorf("%w: unknown key source %q (want \"header:<name>\" or \"ip\")", config.ErrInvalidConfig, src)
+	}
+	return nil
+}
+
+// Extract returns the client key for a request.
+func (k KeyExtractor) Extract(h policies.Headers, ip string) string {
+	for _, src := range k.Sources {
+		var v string
+		if src == "ip" {
+			v = ip
+		} else if name, ok := strings.CutPrefix(src, "header:"); ok {
+			v = h.Get(name)
+		}
+		if v != "" {
+			return v
+		}
+	}
+	return k.Fallback
+}
@@ -0,0 +1,52 @@
+package decision
+
+import (
+	"context"
+	"log/slog"
+)
+
+// LogObserver returns an Observer writing one line per decision to l:
+// requests that were denied, or that shadow mode would have denied, at info,
+// and allowed ones at debug.
+func LogObserver(l *slog.Logger) Observer {
+	return func(req Request, d Decision) {
+		level := slog.LevelDebug
+		if !d.Allowed() || d.Shadowed() {
+			level = slog.LevelInfo
+		}
+		ctx := context.Background()
+		if !l.Enabled(ctx, level) {
+			return
+		}
+
+		attrs := make([]slog.Attr, 0, 11)
+		attrs = append(attrs,
+			slog.String("request_id", req.ID),
+			slog.String("route", req.Route),
+			slog.String("key", req.ClientID),
+			slog.String("outcome", d.Outcome.String()),
+		)
+		if req.Method != "" {
+			attrs = append(attrs, slog.String("method", req.Method))
+		}
+		if d.Reason != "" {
+			attrs = append(attrs, slog.String("reason", d.Reason))
+		}
+		if d.Limited {
+			attrs = append(attrs,
+				slog.String("limiter", d.Limiter),
+				slog.Int("remaining", d.Result.Remaining),
+			)
+			if d.Mode.Shadow() {
+				attrs = append(attrs, slog.String("mode", string(d.Mode)))
+			}
+		}
+		if d.Shadowed() {
+			attrs = append(attrs,
+				slog.String("shadow_outcome", d.ShadowOutcome.String()),
+				slog.String("shadow_reason", d.ShadowReason),
+			)
+		}
+		l.LogAttrs(ctx, level, "decision", attrs...)
+	}
+}
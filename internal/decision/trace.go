@@ -0,0 +1,72 @@
+package decision
+
+import (
+	"context"
+	"strings"
+
+	"fibre_rate_limit_service/internal/policies"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Span attributes describing a decision.
+const (
+	RouteKey     = attribute.Key("ratelimit.route")
+	DecisionKey  = attribute.Key("ratelimit.decision")
+	LimiterKey   = attribute.Key("ratelimit.limiter")
+	RemainingKey = attribute.Key("ratelimit.remaining")
+	AllowedKey   = attribute.Key("ratelimit.allowed")
+	ModeKey      = attribute.Key("ratelimit.mode")
+	ShadowKey    = attribute.Key("ratelimit.shadow_decision")
+)
+
+var tracer = otel.Tracer("fibre_rate_limit_service/decision")
+
+// startSpan starts a child of ctx's span, if that one is recording, and
+// returns a function ending it with the given attributes. Decisions made
+// without a traced request don't create orphan root spans. Spans outlive the
+// request, so attribute strings taken from it are copied.
+func startSpan(ctx context.Context, name, route string) func(...attribute.KeyValue) {
+	if !trace.SpanFromContext(ctx).IsRecording() {
+		return func(...attribute.KeyValue) {}
+	}
+	_, span := tracer.Start(ctx, name, trace.WithAttributes(RouteKey.String(strings.Clone(route))))
+	return func(attrs ...attribute.KeyValue) {
+		span.SetAttributes(attrs...)
+		span.End()
+	}
+}
+
+func policyAttrs(r policies.Result) []attribute.KeyValue {
+	attrs := []attribute.KeyValue{AllowedKey.Bool(r.Allowed)}
+	if r.ShadowDenied {
+		attrs = append(attrs, ShadowKey.String(PolicyDenied.String()))
+	}
+	return attrs
+}
+
+func limiterAttrs(d Decision) []attribute.KeyValue {
+	return []attribute.KeyValue{
+		LimiterKey.String(strings.Clone(d.Limiter)),
+		AllowedKey.Bool(d.Result.Allowed),
+		RemainingKey.Int(d.Result.Remaining),
+		ModeKey.String(string(d.Mode)),
+	}
+}
+
+// annotate records the decision on ctx's span.
+func annotate(ctx context.Context, d Decision) {
+	span := trace.SpanFromContext(ctx)
+	if !span.IsRecording() {
+		return
+	}
+	span.SetAttributes(DecisionKey.String(d.Outcome.String()))
+	if d.Limited {
+		span.SetAttributes(LimiterKey.String(strings.Clone(d.Limiter)), RemainingKey.Int(d.Result.Remaining))
+	}
+	if d.Shadowed() {
+		span.SetAttributes(ShadowKey.String(d.ShadowOutcome.String()))
+	}
+}
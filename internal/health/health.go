@@ -0,0 +1,136 @@
+// Package health tracks whether the service should receive traffic, for the
+// /healthz and /readyz probes.
+package health
+
+import (
+	"errors"
+	"sync"
+	"sync/atomic"
+
+	"fibre_rate_limit_service/internal/storage"
+)
+
+// Reasons a Report gives for not being ready
+var (
+	// ErrConfigNotLoaded is reported until ConfigLoaded is called.
+	ErrConfigNotLoaded = errors.New("config not loaded")
+	// ErrReloading is reported while a Reloading call's done is pending.
+	ErrReloading = errors.New("config reload in progress")
+	// ErrDraining is reported once Drain is called.
+	ErrDraining = errors.New("shutting down")
+	// ErrJanitorStopped is reported by AddStore's check when the store's
+	// expiry sweeper isn't running.
+	ErrJanitorStopped = errors.New("storage janitor not running")
+)
+
+// Readiness combines dependency checks with the service's own state: it isn't
+// ready until the config is loaded, while a config reload is being applied,
+// or once shutdown has started draining requests.
+type Readiness struct {
+	loaded    atomic.Bool
+	reloading atomic.Int32
+	draining  atomic.Bool
+
+	mu     sync.RWMutex
+	checks []check
+}
+
+type check struct {
+	name string
+	fn   func() error
+}
+
+// Report is the outcome of a readiness check. Checks maps each check's name
+// to "ok" or the reason it failed.
+type Report struct {
+	Ready  bool              `json:"ready"`
+	Checks map[string]string `json:"checks"`
+}
+
+// NewReadiness creates a tracker that reports not ready until ConfigLoaded is
+// called.
+func NewReadiness() *Readiness {
+	return &Readiness{}
+}
+
+// AddCheck adds a dependency check, which returns why the dependency isn't
+// usable or nil. Checks run on every probe, so they should be cheap.
+func (r *Readiness) AddCheck(name string, fn func() error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.checks = append(r.checks, check{name: name, fn: fn})
+}
+
+// AddStore checks that store is open and its janitor is sweeping.
+func (r *Readiness) AddStore(store *storage.ShardedMap) {
+	r.AddCheck("storage", store.Ping)
+	r.AddCheck("janitor", func() error {
+		if !store.JanitorRunning() {
+			return ErrJanitorStopped
+		}
+		return nil
+	})
+}
+
+// ConfigLoaded records that the initial config has been applied.
+func (r *Readiness) ConfigLoaded() {
+	r.loaded.Store(true)
+}
+
+// Reloading marks a config reload as in progress until the returned function
+// is called.
+func (r *Readiness) Reloading() (done func()) {
+	r.reloading.Add(1)
+	var once sync.Once
+	return func() {
+		once.Do(func() { r.reloading.Add(-1) })
+	}
+}
+
+// Drain marks the service as shutting down. It stays not ready from then on.
+func (r *Readiness) Drain() {
+	r.draining.Store(true)
+}
+
+// Draining reports whether Drain has been called.
+func (r *Readiness) Draining() bool {
+	return r.draining.Load()
+}
+
+// Check runs the checks. A nil Readiness is always ready.
+func (r *Readiness) Check() Report {
+	rep := Report{Ready: true, Checks: map[string]string{}}
+	if r == nil {
+		return rep
+	}
+	record := func(name string, err error) {
+		if err != nil {
+			rep.Ready = false
+			rep.Checks[name] = err.Error()
+			return
+		}
+		rep.Checks[name] = "ok"
+	}
+
+	switch {
+	case !r.loaded.Load():
+		record("config", ErrConfigNotLoaded)
+	case r.reloading.Load() > 0:
+		record("config", ErrReloading)
+	default:
+		record("config", nil)
+	}
+	if r.draining.Load() {
+		record("shutdown", ErrDraining)
+	} else {
+		record("shutdown", nil)
+	}
+
+	r.mu.RLock()
+	checks := r.checks
+	r.mu.RUnlock()
+	for _, c := range checks {
+		record(c.name, c.fn())
+	}
+	return rep
+}
@@ -0,0 +1,248 @@
+// Package hotkeys tracks each limiter's most active and most denied keys
+// over rolling windows, with a fixed amount of memory per limiter.
+package hotkeys
+
+import (
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"fibre_rate_limit_service/internal/decision"
+)
+
+// Defaults for New: 100 keys per minute, for the last 15 minutes.
+const (
+	DefaultCapacity = 100
+	DefaultSlot     = time.Minute
+	DefaultSlots    = 15
+)
+
+// Tracker keeps a Space-Saving sketch per limiter and time slot, for keys
+// checked against the limiter and for keys it denied. Windows are made of
+// whole slots, so a 5m window over 1m slots covers the last 4-5 minutes.
+// A limiter's sketches are dropped once it has had no requests for longer
+// than MaxWindow, e.g. after it was removed, so they don't outlive it.
+type Tracker struct {
+	capacity int
+	slot     time.Duration
+	slots    int
+	now      func() time.Time
+
+	mu       sync.RWMutex
+	limiters map[string]*limiterKeys
+	swept    atomic.Int64 // epoch of the last sweep for idle limiters
+}
+
+type limiterKeys struct {
+	mu      sync.Mutex
+	active  []slot
+	denied  []slot
+	last    int64 // epoch of the latest request
+	dropped bool  // removed from Tracker.limiters by a sweep
+}
+
+// slot is one ring entry: the sketch for the epoch'th slot since the Unix
+// epoch.
+type slot struct {
+	epoch  int64
+	sketch *spaceSaving
+}
+
+// New creates a tracker keeping capacity keys per slot for slots slots.
+func New(capacity int, slotLen time.Duration, slots int) *Tracker {
+	if capacity <= 0 {
+		capacity = DefaultCapacity
+	}
+	if slotLen <= 0 {
+		slotLen = DefaultSlot
+	}
+	if slots <= 0 {
+		slots = DefaultSlots
+	}
+	return &Tracker{
+		capacity: capacity,
+		slot:     slotLen,
+		slots:    slots,
+		now:      time.Now,
+		limiters: make(map[string]*limiterKeys),
+	}
+}
+
+// MaxWindow is the longest window Top can report on.
+func (t *Tracker) MaxWindow() time.Duration {
+	return t.slot * time.Duration(t.slots)
+}
+
+// Observe is a decision.Observer counting the keys of limited requests.
+// Denials by shadow-mode limiters count as denied, so a trial limit shows
+// who it would affect.
+func (t *Tracker) Observe(req decision.Request, d decision.Decision) {
+	if !d.Limited {
+		return
+	}
+	epoch := t.epoch(t.now())
+	if prev := t.swept.Load(); epoch != prev && t.swept.CompareAndSwap(prev, epoch) {
+		t.sweep(epoch)
+	}
+
+	for {
+		lk := t.keys(d.Limiter)
+		lk.mu.Lock()
+		if lk.dropped {
+			// Swept between lookup and lock; use the fresh entry
+			lk.mu.Unlock()
+			continue
+		}
+		lk.last = epoch
+		t.sketch(lk.active, epoch).add(req.ClientID)
+		if !d.Result.Allowed {
+			t.sketch(lk.denied, epoch).add(req.ClientID)
+		}
+		lk.mu.Unlock()
+		return
+	}
+}
+
+// sweep drops the sketches of limiters with no requests in the slots Top can
+// still report on. It runs once per slot.
+func (t *Tracker) sweep(epoch int64) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	for name, lk := range t.limiters {
+		lk.mu.Lock()
+		if epoch-lk.last >= int64(t.slots) {
+			lk.dropped = true
+			delete(t.limiters, name)
+		}
+		lk.mu.Unlock()
+	}
+}
+
+// keys returns the limiter's sketches, creating them on first use.
+func (t *Tracker) keys(limiter string) *limiterKeys {
+	t.mu.RLock()
+	lk, ok := t.limiters[limiter]
+	t.mu.RUnlock()
+	if ok {
+		return lk
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if lk, ok = t.limiters[limiter]; !ok {
+		lk = &limiterKeys{active: make([]slot, t.slots), denied: make([]slot, t.slots)}
+		t.limiters[strings.Clone(limiter)] = lk
+	}
+	return lk
+}
+
+func (t *Tracker) epoch(now time.Time) int64 {
+	return now.UnixNano() / int64(t.slot)
+}
+
+// sketch returns the ring's sketch for epoch, recycling a stale one.
+func (t *Tracker) sketch(ring []slot, epoch int64) *spaceSaving {
+	s := &ring[int(epoch%int64(len(ring)))]
+	switch {
+	case s.sketch == nil:
+		s.sketch = newSpaceSaving(t.capacity)
+	case s.epoch != epoch:
+		s.sketch.reset()
+	}
+	s.epoch = epoch
+	return s.sketch
+}
+
+// Report lists a limiter's top keys over a window, highest count first.
+type Report struct {
+	Limiter string  `json:"limiter"`
+	Window  string  `json:"window"`
+	Active  []Count `json:"active"`
+	Denied  []Count `json:"denied"`
+}
+
+// Top returns up to n of the limiter's most active and most denied keys over
+// the window, which is rounded up to whole slots and capped at MaxWindow.
+func (t *Tracker) Top(limiter string, window time.Duration, n int) Report {
+	slots := int((window + t.slot - 1) / t.slot)
+	if slots < 1 {
+		slots = 1
+	}
+	if slots > t.slots {
+		slots = t.slots
+	}
+	out := Report{
+		Limiter: limiter,
+		Window:  (time.Duration(slots) * t.slot).String(),
+		Active:  []Count{},
+		Denied:  []Count{},
+	}
+
+	t.mu.RLock()
+	lk, ok := t.limiters[limiter]
+	t.mu.RUnlock()
+	if !ok {
+		return out
+	}
+
+	from := t.epoch(t.now()) - int64(slots) + 1
+	lk.mu.Lock()
+	out.Active = t.merge(lk.active, from, n)
+	out.Denied = t.merge(lk.denied, from, n)
+	lk.mu.Unlock()
+	return out
+}
+
+// merge sums the counts of the ring's sketches from epoch from onwards and
+// returns the top n. A key missing from a full sketch may have been evicted
+// from it, so it is charged that sketch's smallest count as error.
+func (t *Tracker) merge(ring []slot, from int64, n int) []Count {
+	var live []*spaceSaving
+	for _, s := range ring {
+		if s.sketch != nil && s.epoch >= from && len(s.sketch.min) > 0 {
+			live = append(live, s.sketch)
+		}
+	}
+
+	totals := make(map[string]*Count)
+	for _, sk := range live {
+		for key, c := range sk.index {
+			tot, ok := totals[key]
+			if !ok {
+				tot = &Count{Key: key}
+				totals[key] = tot
+			}
+			tot.Count += c.Count.Count
+			tot.Error += c.Error
+		}
+	}
+	for _, sk := range live {
+		if len(sk.min) < sk.capacity {
+			continue
+		}
+		floor := sk.min[0].Count.Count
+		for key, tot := range totals {
+			if _, ok := sk.index[key]; !ok {
+				tot.Count += floor
+				tot.Error += floor
+			}
+		}
+	}
+
+	out := make([]Count, 0, len(totals))
+	for _, c := range totals {
+		out = append(out, *c)
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Count != out[j].Count {
+			return out[i].Count > out[j].Count
+		}
+		return out[i].Key < out[j].Key
+	})
+	if n > 0 && len(out) > n {
+		out = out[:n]
+	}
+	return out
+}
@@ -0,0 +1,101 @@
+package hotkeys
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"fibre_rate_limit_service/internal/decision"
+	"fibre_rate_limit_service/internal/limiters"
+)
+
+func observe(t *Tracker, key string, allowed bool, times int) {
+	for i := 0; i < times; i++ {
+		t.Observe(
+			decision.Request{ClientID: key, Route: "/check"},
+			decision.Decision{Limited: true, Limiter: "/check", Result: limiters.Result{Allowed: allowed}},
+		)
+	}
+}
+
+func TestTopRollingWindows(t *testing.T) {
+	now := time.Unix(1_700_000_000, 0)
+	tr := New(4, time.Minute, 5)
+	tr.now = func() time.Time { return now }
+
+	observe(tr, "old", false, 50)
+	now = now.Add(3 * time.Minute)
+	observe(tr, "alice", true, 30)
+	observe(tr, "bob", false, 20)
+	observe(tr, "carol", true, 5)
+
+	r := tr.Top("/check", time.Minute, 2)
+	if r.Window != "1m0s" {
+		t.Errorf("window = %s, want 1m0s", r.Window)
+	}
+	if len(r.Active) != 2 || r.Active[0].Key != "alice" || r.Active[1].Key != "bob" {
+		t.Errorf("active = %+v, want alice then bob", r.Active)
+	}
+	if len(r.Denied) != 1 || r.Denied[0] != (Count{Key: "bob", Count: 20}) {
+		t.Errorf("denied = %+v, want bob:20", r.Denied)
+	}
+
+	r = tr.Top("/check", 5*time.Minute, 10)
+	if r.Denied[0] != (Count{Key: "old", Count: 50}) {
+		t.Errorf("denied over 5m = %+v, want old:50 first", r.Denied)
+	}
+
+	// The first slot falls out of the ring once it's older than the window
+	now = now.Add(2 * time.Minute)
+	if r = tr.Top("/check", time.Hour, 10); r.Window != "5m0s" || len(r.Denied) != 1 || r.Denied[0].Key != "bob" {
+		t.Errorf("after expiry = %+v, want only bob denied over 5m", r)
+	}
+}
+
+func TestIdleLimitersAreDropped(t *testing.T) {
+	now := time.Unix(1_700_000_000, 0)
+	tr := New(4, time.Minute, 5)
+	tr.now = func() time.Time { return now }
+
+	observe(tr, "alice", true, 3)
+	tr.Observe(
+		decision.Request{ClientID: "bob", Route: "/other"},
+		decision.Decision{Limited: true, Limiter: "/other", Result: limiters.Result{Allowed: true}},
+	)
+
+	// /other stops getting requests, e.g. because it was removed; /check
+	// keeps going
+	for i := 0; i < 5; i++ {
+		now = now.Add(time.Minute)
+		observe(tr, "alice", true, 1)
+	}
+	tr.mu.RLock()
+	_, other := tr.limiters["/other"]
+	_, check := tr.limiters["/check"]
+	tr.mu.RUnlock()
+	if other || !check {
+		t.Fatalf("after 5 idle minutes: /other kept = %v, /check kept = %v", other, check)
+	}
+	if r := tr.Top("/check", 5*time.Minute, 10); len(r.Active) != 1 || r.Active[0].Count != 5 {
+		t.Fatalf("/check top = %+v, want alice:5", r.Active)
+	}
+}
+
+func TestSpaceSavingKeepsHeavyHitters(t *testing.T) {
+	s := newSpaceSaving(3)
+	for i := 0; i < 100; i++ {
+		s.add("hot")
+		s.add(fmt.Sprintf("cold-%d", i))
+	}
+
+	c, ok := s.index["hot"]
+	if !ok {
+		t.Fatal("heavy hitter was evicted")
+	}
+	if c.Count.Count < 100 || c.Count.Count-c.Error > 100 {
+		t.Errorf("hot = %+v, want bounds around 100", c.Count)
+	}
+	if len(s.index) != 3 || len(s.min) != 3 {
+		t.Errorf("tracked %d keys, want 3", len(s.index))
+	}
+}
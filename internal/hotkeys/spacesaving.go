@@ -0,0 +1,92 @@
+package hotkeys
+
+import (
+	"container/heap"
+	"strings"
+)
+
+// Count is a key's estimated count. The true count lies between
+// Count-Error and Count.
+type Count struct {
+	Key   string `json:"key"`
+	Count uint64 `json:"count"`
+	Error uint64 `json:"error"`
+}
+
+// spaceSaving is the Space-Saving heavy-hitters sketch: it tracks at most
+// capacity keys, and a new key replaces the smallest one, inheriting its
+// count as error. Any key seen more than total/capacity times is kept.
+type spaceSaving struct {
+	capacity int
+	index    map[string]*counter
+	min      counterHeap
+}
+
+type counter struct {
+	Count
+	pos int // position in the heap
+}
+
+func newSpaceSaving(capacity int) *spaceSaving {
+	return &spaceSaving{
+		capacity: capacity,
+		index:    make(map[string]*counter, capacity),
+	}
+}
+
+// add counts one occurrence of key. Keys are copied when stored, since
+// request strings may be reused.
+func (s *spaceSaving) add(key string) {
+	if c, ok := s.index[key]; ok {
+		c.Count.Count++
+		heap.Fix(&s.min, c.pos)
+		return
+	}
+	key = strings.Clone(key)
+	if len(s.min) < s.capacity {
+		c := &counter{Count: Count{Key: key, Count: 1}}
+		s.index[key] = c
+		heap.Push(&s.min, c)
+		return
+	}
+
+	// Evict the smallest key; the newcomer may have been it all along.
+	c := s.min[0]
+	delete(s.index, c.Key)
+	c.Key = key
+	c.Error = c.Count.Count
+	c.Count.Count++
+	s.index[key] = c
+	heap.Fix(&s.min, 0)
+}
+
+// reset empties the sketch.
+func (s *spaceSaving) reset() {
+	s.index = make(map[string]*counter, s.capacity)
+	s.min = s.min[:0]
+}
+
+// counterHeap is a min-heap of counters by count.
+type counterHeap []*counter
+
+func (h counterHeap) Len() int           { return len(h) }
+func (h counterHeap) Less(i, j int) bool { return h[i].Count.Count < h[j].Count.Count }
+
+func (h counterHeap) Swap(i, j int) {
+	h[i], h[j] = h[j], h[i]
+	h[i].pos = i
+	h[j].pos = j
+}
+
+func (h *counterHeap) Push(x interface{}) {
+	c := x.(*counter)
+	c.pos = len(*h)
+	*h = append(*h, c)
+}
+
+func (h *counterHeap) Pop() interface{} {
+	old := *h
+	c := old[len(old)-1]
+	*h = old[:len(old)-1]
+	return c
+}
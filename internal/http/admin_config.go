@@ -0,0 +1,152 @@
+package http
+
+import (
+	"errors"
+	"net/url"
+	"strconv"
+
+	"fibre_rate_limit_service/internal/audit"
+	"fibre_rate_limit_service/internal/config"
+	"fibre_rate_limit_service/internal/config/configfile"
+	"fibre_rate_limit_service/internal/decision"
+	"fibre_rate_limit_service/internal/health"
+	"fibre_rate_limit_service/internal/limiters"
+	"fibre_rate_limit_service/internal/policies"
+	"fibre_rate_limit_service/internal/storage"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/utils"
+)
+
+// AdminGetConfigHandler handles GET /admin/config. It exports the live
+// limiters, overrides, policies and key extractor in the config file format,
+// as JSON or, with ?format=yaml, as YAML.
+func AdminGetConfigHandler(c *fiber.Ctx, lm *limiters.Manager, pe *policies.Evaluator, d *decision.Decider) error {
+	cfg := configfile.Current(lm, pe, d)
+
+	switch c.Query("format", "json") {
+	case "json":
+		return c.JSON(cfg)
+	case "yaml":
+		out, err := cfg.YAML()
+		if err != nil {
+			return JSONError(c, fiber.StatusInternalServerError, "failed to encode config")
+		}
+		c.Set(fiber.HeaderContentType, "application/yaml")
+		return c.Send(out)
+	}
+	return JSONError(c, fiber.StatusBadRequest, "format must be json or yaml")
+}
+
+// AdminApplyConfigHandler handles POST /admin/config/apply. The body is a
+// full config in the file format (YAML or JSON) and replaces the live
+// limiters, policies and key extractor; anything it doesn't list is removed.
+// With ?dry_run=true it only reports what would change. The service reports
+// not ready while the config is applied, as for a file reload.
+func AdminApplyConfigHandler(c *fiber.Ctx, lm *limiters.Manager, pe *policies.Evaluator, d *decision.Decider, store *storage.ShardedMap, al *audit.Log, ready *health.Readiness) error {
+	next, err := configfile.Parse("request body", c.Body())
+	if err != nil {
+		return configError(c, err)
+	}
+
+	before := configfile.Current(lm, pe, d)
+	if c.QueryBool("dry_run") {
+		return c.JSON(fiber.Map{
+			"dry_run": true,
+			"diff":    configfile.Compare(before, next),
+		})
+	}
+
+	done := ready.Reloading()
+	diff, err := next.Apply(lm, pe, d, store)
+	done()
+	if err != nil {
+		return configError(c, err)
+	}
+	if !diff.Empty() {
+		recordAction(c, al, "config.apply", "", before, configfile.Current(lm, pe, d))
+	}
+	return c.JSON(fiber.Map{
+		"dry_run": false,
+		"diff":    diff,
+	})
+}
+
+// commitVersions records a config version after every successful admin
+// request that changes limiters, policies or the key extractor.
+func commitVersions(h *configfile.History) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		if err := c.Next(); err != nil {
+			return err
+		}
+		if c.Method() == fiber.MethodGet || c.Response().StatusCode() >= fiber.StatusMultipleChoices {
+			return nil
+		}
+		path, err := url.PathUnescape(c.Path())
+		if err != nil {
+			path = c.Path()
+		}
+		path = utils.CopyString(path)
+		h.Commit(actor(c), c.Method()+" "+path)
+		return nil
+	}
+}
+
+// AdminListVersionsHandler handles GET /admin/config/versions
+func AdminListVersionsHandler(c *fiber.Ctx, h *configfile.History) error {
+	return c.JSON(h.Versions())
+}
+
+// AdminGetVersionHandler handles GET /admin/config/versions/:version. The
+// "config" field is in the config file format.
+func AdminGetVersionHandler(c *fiber.Ctx, h *configfile.History) error {
+	version, err := c.ParamsInt("version")
+	if err != nil {
+		return JSONError(c, fiber.StatusBadRequest, "invalid version")
+	}
+
+	v, ok := h.Get(version)
+	if !ok {
+		return JSONError(c, fiber.StatusNotFound, "version not found")
+	}
+	return c.JSON(fiber.Map{
+		"version": v,
+		"config":  v.Config,
+	})
+}
+
+// AdminRollbackConfigHandler handles POST /admin/config/rollback/:version.
+// The restored state is recorded as a new version. The service reports not
+// ready while it is applied.
+func AdminRollbackConfigHandler(c *fiber.Ctx, h *configfile.History, store *storage.ShardedMap, al *audit.Log, ready *health.Readiness) error {
+	version, err := c.ParamsInt("version")
+	if err != nil {
+		return JSONError(c, fiber.StatusBadRequest, "invalid version")
+	}
+
+	before := h.Current()
+	done := ready.Reloading()
+	diff, err := h.Rollback(version, store)
+	done()
+	if errors.Is(err, configfile.ErrVersionNotFound) {
+		return JSONError(c, fiber.StatusNotFound, "version not found")
+	}
+	if err != nil {
+		return configError(c, err)
+	}
+	if !diff.Empty() {
+		recordAction(c, al, "config.rollback", strconv.Itoa(version), before, h.Current())
+	}
+	return c.JSON(fiber.Map{
+		"message": "rolled back to version " + strconv.Itoa(version),
+		"diff":    diff,
+	})
+}
+
+// configError maps config parsing and validation errors to responses.
+func configError(c *fiber.Ctx, err error) error {
+	if errors.Is(err, config.ErrInvalidConfig) {
+		return JSONError(c, fiber.StatusBadRequest, err.Error())
+	}
+	return JSONError(c, fiber.StatusInternalServerError, "failed to apply config")
+}
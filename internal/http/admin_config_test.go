@@ -0,0 +1,117 @@
+package http
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func TestAdminConfig_ExportAndApply(t *testing.T) {
+	app, lm := newTestApp(t)
+
+	doJSON(t, app, "PUT", "/admin/limiters/%2Fcheck",
+		`{"type":"token-bucket","capacity":5,"refill_rate":1,"refill_every":2,"ttl":30,"overrides":[{"key":"acme:*","capacity":50,"refill_rate":10,"refill_every":1,"ttl":30}]}`)
+	doJSON(t, app, "POST", "/admin/policies", `{"route":"/check","header":"X-Secret","value":"123"}`)
+
+	status, exported := doJSON(t, app, "GET", "/admin/config?format=yaml", "")
+	if status != fiber.StatusOK || !strings.Contains(exported, "acme:*") || !strings.Contains(exported, `value: "123"`) {
+		t.Fatalf("export: unexpected %d\n%s", status, exported)
+	}
+
+	// Re-applying the export changes nothing.
+	status, body := doJSON(t, app, "POST", "/admin/config/apply", exported)
+	var res struct {
+		Diff struct {
+			Limiters struct{ Added, Removed, Modified []string }
+			Policies struct{ Added, Removed, Modified []string }
+		}
+	}
+	json.Unmarshal([]byte(body), &res)
+	if status != fiber.StatusOK || len(res.Diff.Limiters.Modified)+len(res.Diff.Policies.Modified) != 0 {
+		t.Fatalf("round trip: unexpected %d %s", status, body)
+	}
+
+	next := `{"limiters":[{"name":"/orders","type":"fixed-window","limit":10,"window":60}]}`
+	status, body = doJSON(t, app, "POST", "/admin/config/apply?dry_run=true", next)
+	if status != fiber.StatusOK || !strings.Contains(body, `"added":["/orders"]`) || !strings.Contains(body, `"removed":["/check"]`) {
+		t.Fatalf("dry run: unexpected %d %s", status, body)
+	}
+	if _, ok := lm.GetLimiter("/orders"); ok {
+		t.Fatal("dry run must not apply changes")
+	}
+
+	status, body = doJSON(t, app, "POST", "/admin/config/apply", next)
+	if status != fiber.StatusOK {
+		t.Fatalf("apply: unexpected %d %s", status, body)
+	}
+	if _, ok := lm.GetLimiter("/orders"); !ok {
+		t.Fatal("expected /orders after apply")
+	}
+	if _, ok := lm.GetLimiter("/check"); ok {
+		t.Fatal("expected /check to be removed by apply")
+	}
+
+	status, body = doJSON(t, app, "POST", "/admin/config/apply", "limiters:\n  - name: /x\n    type: fixed-window\n    limit: 0\n")
+	if status != fiber.StatusBadRequest || !strings.Contains(body, "request body:2: limiters[0]") {
+		t.Fatalf("invalid: unexpected %d %s", status, body)
+	}
+	if _, ok := lm.GetLimiter("/orders"); !ok {
+		t.Fatal("a rejected apply must leave the config unchanged")
+	}
+}
+
+func TestAdminConfig_VersionsAndRollback(t *testing.T) {
+	app, lm := newTestApp(t)
+
+	doJSON(t, app, "PUT", "/admin/limiters/%2Fcheck",
+		`{"type":"token-bucket","capacity":5,"refill_rate":1,"refill_every":2,"ttl":30}`)
+	doJSON(t, app, "PUT", "/admin/limiters/%2Fcheck",
+		`{"type":"token-bucket","capacity":50,"refill_rate":1,"refill_every":2,"ttl":30}`)
+	// Reads and key operations don't create versions.
+	doJSON(t, app, "DELETE", "/admin/limiters/%2Fcheck/keys/alice", "")
+
+	status, body := doJSON(t, app, "GET", "/admin/config/versions", "")
+	var versions []struct {
+		Version int
+		Author  string
+		Action  string
+		Diff    struct {
+			Limiters struct{ Added, Modified []string }
+		}
+	}
+	json.Unmarshal([]byte(body), &versions)
+	if status != fiber.StatusOK || len(versions) != 3 {
+		t.Fatalf("versions: unexpected %d %s", status, body)
+	}
+	if v := versions[0]; v.Version != 3 || v.Action != "PUT /admin/limiters//check" || len(v.Diff.Limiters.Modified) != 1 {
+		t.Fatalf("unexpected latest version %+v", v)
+	}
+
+	status, body = doJSON(t, app, "POST", "/admin/config/rollback/2", "")
+	if status != fiber.StatusOK || !strings.Contains(body, `"modified":["/check"]`) {
+		t.Fatalf("rollback: unexpected %d %s", status, body)
+	}
+	if def, _ := lm.Definition("/check"); !strings.Contains(mustJSON(t, def), `"capacity":5,`) {
+		t.Fatalf("expected capacity 5 after rollback, got %s", mustJSON(t, def))
+	}
+
+	_, body = doJSON(t, app, "GET", "/admin/config/versions/4", "")
+	if !strings.Contains(body, `"action":"POST /admin/config/rollback/2"`) {
+		t.Fatalf("expected the rollback to be recorded, got %s", body)
+	}
+
+	if status, _ := doJSON(t, app, "POST", "/admin/config/rollback/99", ""); status != fiber.StatusNotFound {
+		t.Fatalf("unknown version: expected 404, got %d", status)
+	}
+}
+
+func mustJSON(t *testing.T, v interface{}) string {
+	t.Helper()
+	data, err := json.Marshal(v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(data)
+}
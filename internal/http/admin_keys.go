@@ -0,0 +1,111 @@
+package http
+
+import (
+	"encoding/json"
+	"errors"
+
+	"fibre_rate_limit_service/internal/audit"
+	"fibre_rate_limit_service/internal/config"
+	"fibre_rate_limit_service/internal/limiters"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// GrantRequest represents the JSON body of PUT /admin/limiters/:name/keys/:key
+type GrantRequest struct {
+	Tokens int `json:"tokens"` // extra requests granted to the key
+}
+
+// AdminGetKeyHandler handles GET /admin/limiters/:name/keys/:key
+func AdminGetKeyHandler(c *fiber.Ctx, lm *limiters.Manager) error {
+	name, key, km, err := keyManager(c, lm)
+	if err != nil {
+		return keyError(c, err)
+	}
+
+	state, found := km.KeyState(key)
+	return c.JSON(fiber.Map{
+		"limiter": name,
+		"state":   state,
+		"stored":  found,
+	})
+}
+
+// AdminResetKeyHandler handles DELETE /admin/limiters/:name/keys/:key
+func AdminResetKeyHandler(c *fiber.Ctx, lm *limiters.Manager, al *audit.Log) error {
+	name, key, km, err := keyManager(c, lm)
+	if err != nil {
+		return keyError(c, err)
+	}
+
+	before, _ := km.KeyState(key)
+	km.ResetKey(key)
+	after, _ := km.KeyState(key)
+
+	recordAction(c, al, "key.reset", name+"/"+key, before, after)
+	return c.JSON(fiber.Map{
+		"limiter": name,
+		"state":   after,
+	})
+}
+
+// AdminGrantKeyHandler handles PUT /admin/limiters/:name/keys/:key
+func AdminGrantKeyHandler(c *fiber.Ctx, lm *limiters.Manager, al *audit.Log) error {
+	name, key, km, err := keyManager(c, lm)
+	if err != nil {
+		return keyError(c, err)
+	}
+
+	var req GrantRequest
+	if err := json.Unmarshal(c.Body(), &req); err != nil {
+		return JSONError(c, fiber.StatusBadRequest, "invalid request body")
+	}
+	if req.Tokens <= 0 {
+		return JSONError(c, fiber.StatusBadRequest, config.ErrInvalidConfig.Error()+": tokens must be positive")
+	}
+
+	before, _ := km.KeyState(key)
+	after := km.GrantKey(key, req.Tokens)
+
+	recordAction(c, al, "key.grant", name+"/"+key, before, after)
+	return c.JSON(fiber.Map{
+		"limiter": name,
+		"state":   after,
+	})
+}
+
+var (
+	errLimiterNotFound = errors.New("limiter not found")
+	errNoKeyOps        = errors.New("limiter does not support per-key operations")
+	errInvalidParam    = errors.New("invalid limiter name or key")
+)
+
+// keyManager resolves the limiter and key path params.
+func keyManager(c *fiber.Ctx, lm *limiters.Manager) (string, string, limiters.KeyManager, error) {
+	name, err := pathParam(c, "name")
+	if err != nil {
+		return "", "", nil, errInvalidParam
+	}
+	key, err := pathParam(c, "key")
+	if err != nil {
+		return "", "", nil, errInvalidParam
+	}
+
+	// Use the override for this key, if any, so limits match what /check sees
+	l, _, ok := lm.Resolve(name, key)
+	if !ok {
+		return "", "", nil, errLimiterNotFound
+	}
+	km, ok := l.(limiters.KeyManager)
+	if !ok {
+		return "", "", nil, errNoKeyOps
+	}
+	return name, key, km, nil
+}
+
+func keyError(c *fiber.Ctx, err error) error {
+	if errors.Is(err, errLimiterNotFound) {
+		return JSONError(c, fiber.StatusNotFound, err.Error())
+	}
+	return JSONError(c, fiber.StatusBadRequest, err.Error())
+}
@@ -0,0 +1,115 @@
+package http
+
+import (
+	"bytes"
+	"io"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"fibre_rate_limit_service/internal/audit"
+	"fibre_rate_limit_service/internal/limiters"
+	"fibre_rate_limit_service/internal/policies"
+	"fibre_rate_limit_service/internal/storage"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func TestAdminKeys_InspectGrantReset(t *testing.T) {
+	store := storage.NewShardedMap(4, time.Minute, time.Minute)
+	defer store.Close()
+
+	lm := limiters.NewManager()
+	tb := limiters.NewTokenBucket(limiters.TokenBucketConfig{
+		Name:        "/orders",
+		Capacity:    2,
+		RefillRate:  1,
+		RefillEvery: time.Hour,
+	}, store)
+	lm.SetLimiter("/orders", tb)
+
+	var auditBuf bytes.Buffer
+	app := fiber.New()
+	SetupRouter(app, Services{Limiters: lm, Policies: policies.NewEvaluator(), Store: store, Audit: audit.New(&auditBuf)})
+
+	tb.Check("acme")
+	tb.Check("acme")
+
+	keyURL := "/admin/limiters/%2Forders/keys/acme"
+	status, body := doJSON(t, app, "GET", keyURL, "")
+	if status != fiber.StatusOK || !strings.Contains(body, `"remaining":0`) || !strings.Contains(body, `"stored":true`) {
+		t.Fatalf("GET key: unexpected %d %s", status, body)
+	}
+
+	status, body = doJSON(t, app, "PUT", keyURL, `{"tokens":3}`)
+	if status != fiber.StatusOK || !strings.Contains(body, `"remaining":3`) {
+		t.Fatalf("grant: unexpected %d %s", status, body)
+	}
+	if !tb.Check("acme").Allowed {
+		t.Fatal("expected granted token to allow a request")
+	}
+
+	status, body = doJSON(t, app, "DELETE", keyURL, "")
+	if status != fiber.StatusOK || !strings.Contains(body, `"remaining":2`) {
+		t.Fatalf("reset: unexpected %d %s", status, body)
+	}
+
+	status, _ = doJSON(t, app, "GET", "/admin/limiters/%2Fmissing/keys/acme", "")
+	if status != fiber.StatusNotFound {
+		t.Fatalf("expected 404 for unknown limiter, got %d", status)
+	}
+
+	lines := strings.Split(strings.TrimSpace(auditBuf.String()), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("expected 2 audit entries, got %d: %s", len(lines), auditBuf.String())
+	}
+	for i, action := range []string{"key.grant", "key.reset"} {
+		if !strings.Contains(lines[i], `"action":"`+action+`"`) || !strings.Contains(lines[i], `"target":"/orders/acme"`) {
+			t.Fatalf("audit entry %d: unexpected %s", i, lines[i])
+		}
+	}
+}
+
+func TestAdminTopKeys(t *testing.T) {
+	store := storage.NewShardedMap(4, time.Minute, time.Minute)
+	defer store.Close()
+
+	lm := limiters.NewManager()
+	lm.SetLimiter("/check", limiters.NewTokenBucket(limiters.TokenBucketConfig{
+		Name:        "/check",
+		Capacity:    1,
+		RefillRate:  1,
+		RefillEvery: time.Hour,
+	}, store))
+	app := fiber.New()
+	SetupRouter(app, Services{Limiters: lm, Policies: policies.NewEvaluator(), Store: store, Audit: audit.New(io.Discard)})
+
+	for _, key := range []string{"noisy", "noisy", "noisy", "quiet"} {
+		req := httptest.NewRequest("POST", "/check", nil)
+		req.Header.Set("X-Client-ID", key)
+		if _, err := app.Test(req); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	status, body := doJSON(t, app, "GET", "/admin/limiters/%2Fcheck/top?window=1m&n=5", "")
+	if status != fiber.StatusOK {
+		t.Fatalf("top: unexpected %d %s", status, body)
+	}
+	for _, want := range []string{
+		`"active":[{"key":"noisy","count":3,"error":0},{"key":"quiet","count":1,"error":0}]`,
+		`"denied":[{"key":"noisy","count":2,"error":0}]`,
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("top: missing %s in %s", want, body)
+		}
+	}
+
+	if status, _ = doJSON(t, app, "GET", "/admin/limiters/%2Fcheck/top?window=soon", ""); status != fiber.StatusBadRequest {
+		t.Errorf("bad window: expected 400, got %d", status)
+	}
+	if status, _ = doJSON(t, app, "GET", "/admin/limiters/%2Fmissing/top", ""); status != fiber.StatusNotFound {
+		t.Errorf("unknown limiter: expected 404, got %d", status)
+	}
+}
@@ -2,53 +2,144 @@ package http
 
 import (
 	"encoding/json"
-	"time"
+	"errors"
+	"sort"
 
+	"fibre_rate_limit_service/internal/audit"
+	"fibre_rate_limit_service/internal/config"
+	"fibre_rate_limit_service/internal/decision"
 	"fibre_rate_limit_service/internal/limiters"
 	"fibre_rate_limit_service/internal/storage"
 
 	"github.com/gofiber/fiber/v2"
 )
 
-// LimiterRequest represents the JSON body for creating/updating a limiter
-type LimiterRequest struct {
-	Name        string `json:"name"`
-	Type        string `json:"type"` // e.g., "token-bucket"
-	Capacity    int  `json:"capacity"`
-	RefillRate  int  `json:"refill_rate"`
-	RefillEvery int  `json:"refill_every"` // seconds
-	TTL         int  `json:"ttl"`          // seconds
-}
+// LimiterRequest represents the JSON body for creating/updating a limiter:
+// "name" and "type" plus the fields of that type's spec, e.g.
+// {"name": "/check", "type": "token-bucket", "capacity": 5, "refill_rate": 1,
+// "refill_every": 2, "ttl": 30}. An optional "overrides" array replaces the
+// limiter's per-key overrides; when absent they are kept.
+type LimiterRequest = limiters.Definition
 
 // AdminLimitersHandler handles POST /admin/limiters
-func AdminLimitersHandler(c *fiber.Ctx, lm *limiters.Manager, store *storage.ShardedMap) error {
+func AdminLimitersHandler(c *fiber.Ctx, lm *limiters.Manager, d *decision.Decider, store *storage.ShardedMap, al *audit.Log) error {
 	var req LimiterRequest
 	if err := json.Unmarshal(c.Body(), &req); err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error": "invalid request body",
-		})
+		return limiterError(c, err)
 	}
 
-	// Currently only support Token-Bucket
-	if req.Type != "token-bucket" {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error": "unsupported limiter type",
-		})
+	before, existed, after, err := setLimiter(lm, d, store, req)
+	if err != nil {
+		return limiterError(c, err)
 	}
-
-	// Create TokenBucket
-	tb := limiters.NewTokenBucket(limiters.TokenBucketConfig{
-		Name:        req.Name,
-		Capacity:    int(req.Capacity),
-		RefillRate:  int(req.RefillRate),
-		RefillEvery: time.Duration(req.RefillEvery) * time.Second,
-		TTL:         time.Duration(req.TTL) * time.Second,
-	}, store)
-
-	// Hot-reload: add or replace limiter
-	lm.SetLimiter(req.Name, tb)
+	recordAction(c, al, "limiter.set", req.Name, orNil(before, existed), after)
 
 	return c.Status(fiber.StatusOK).JSON(fiber.Map{
 		"message": "limiter added/updated successfully",
 	})
 }
+
+// AdminListLimitersHandler handles GET /admin/limiters
+func AdminListLimitersHandler(c *fiber.Ctx, lm *limiters.Manager) error {
+	all := lm.Limiters()
+
+	names := make([]string, 0, len(all))
+	for name := range all {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	out := make([]LimiterRequest, 0, len(names))
+	for _, name := range names {
+		if def, ok := lm.Definition(name); ok {
+			out = append(out, def)
+		}
+	}
+	return c.JSON(out)
+}
+
+// AdminGetLimiterHandler handles GET /admin/limiters/:name
+func AdminGetLimiterHandler(c *fiber.Ctx, lm *limiters.Manager) error {
+	name, err := pathParam(c, "name")
+	if err != nil {
+		return JSONError(c, fiber.StatusBadRequest, "invalid limiter name")
+	}
+
+	def, ok := lm.Definition(name)
+	if !ok {
+		return JSONError(c, fiber.StatusNotFound, "limiter not found")
+	}
+	return c.JSON(def)
+}
+
+// AdminPutLimiterHandler handles PUT /admin/limiters/:name
+func AdminPutLimiterHandler(c *fiber.Ctx, lm *limiters.Manager, d *decision.Decider, store *storage.ShardedMap, al *audit.Log) error {
+	name, err := pathParam(c, "name")
+	if err != nil {
+		return JSONError(c, fiber.StatusBadRequest, "invalid limiter name")
+	}
+
+	var req LimiterRequest
+	if err := json.Unmarshal(c.Body(), &req); err != nil {
+		return limiterError(c, err)
+	}
+	if req.Name != "" && req.Name != name {
+		return JSONError(c, fiber.StatusBadRequest, "name in body does not match path")
+	}
+	req.Name = name
+
+	before, existed, def, err := setLimiter(lm, d, store, req)
+	if err != nil {
+		return limiterError(c, err)
+	}
+	recordAction(c, al, "limiter.set", name, orNil(before, existed), def)
+	return c.JSON(def)
+}
+
+// AdminDeleteLimiterHandler handles DELETE /admin/limiters/:name
+func AdminDeleteLimiterHandler(c *fiber.Ctx, lm *limiters.Manager, d *decision.Decider, al *audit.Log) error {
+	name, err := pathParam(c, "name")
+	if err != nil {
+		return JSONError(c, fiber.StatusBadRequest, "invalid limiter name")
+	}
+
+	var (
+		before  LimiterRequest
+		removed bool
+	)
+	d.Update(func() error {
+		before, _ = lm.Definition(name)
+		removed = lm.RemoveLimiter(name)
+		return nil
+	})
+	if !removed {
+		return JSONError(c, fiber.StatusNotFound, "limiter not found")
+	}
+	recordAction(c, al, "limiter.delete", name, before, nil)
+	return c.JSON(fiber.Map{
+		"message": "limiter deleted successfully",
+	})
+}
+
+// setLimiter validates the request and adds or replaces the limiter under
+// the decider's update lock, returning its definition before and after.
+func setLimiter(lm *limiters.Manager, d *decision.Decider, store *storage.ShardedMap, req LimiterRequest) (before LimiterRequest, existed bool, after LimiterRequest, err error) {
+	err = d.Update(func() error {
+		before, existed = lm.Definition(req.Name)
+		// Hot-reload: add or replace limiter
+		if err := lm.Apply(req, store); err != nil {
+			return err
+		}
+		after, _ = lm.Definition(req.Name)
+		return nil
+	})
+	return before, existed, after, err
+}
+
+// limiterError maps decoding and validation errors to responses.
+func limiterError(c *fiber.Ctx, err error) error {
+	if errors.Is(err, config.ErrInvalidConfig) {
+		return JSONError(c, fiber.StatusBadRequest, err.Error())
+	}
+	return JSONError(c, fiber.StatusBadRequest, "invalid request body")
+}
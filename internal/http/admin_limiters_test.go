@@ -0,0 +1,170 @@
+package http
+
+import (
+	"encoding/json"
+	"io"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"fibre_rate_limit_service/internal/audit"
+	"fibre_rate_limit_service/internal/decision"
+	"fibre_rate_limit_service/internal/limiters"
+	"fibre_rate_limit_service/internal/policies"
+	"fibre_rate_limit_service/internal/storage"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func newTestApp(t *testing.T) (*fiber.App, *limiters.Manager) {
+	t.Helper()
+	store := storage.NewShardedMap(4, time.Minute, time.Minute)
+	t.Cleanup(store.Close)
+
+	app := fiber.New()
+	lm := limiters.NewManager()
+	SetupRouter(app, Services{Limiters: lm, Policies: policies.NewEvaluator(), Store: store, Audit: audit.New(io.Discard)})
+	return app, lm
+}
+
+// newCheckTestApp serves the check routes with a one-token bucket on each of
+// limited and an X-Secret: 123 policy on /admin. The returned request holds
+// the route and method of the last decision.
+func newCheckTestApp(t *testing.T, limited ...string) (*fiber.App, *decision.Request) {
+	t.Helper()
+	store := storage.NewShardedMap(4, time.Minute, time.Minute)
+	t.Cleanup(store.Close)
+
+	lm := limiters.NewManager()
+	for _, route := range limited {
+		lm.SetLimiter(route, limiters.NewTokenBucket(limiters.TokenBucketConfig{
+			Name:        route,
+			Capacity:    1,
+			RefillRate:  1,
+			RefillEvery: time.Hour,
+		}, store))
+	}
+	pe := policies.NewEvaluator()
+	pe.AddRule("/admin", policies.Rule{Header: "X-Secret", Value: "123"})
+
+	d := decision.NewDecider(lm, pe)
+	last := &decision.Request{}
+	d.Observe(func(req decision.Request, _ decision.Decision) {
+		*last = decision.Request{Route: req.Route, Method: req.Method}
+	})
+
+	app := fiber.New()
+	SetupCheckRoutes(app, Services{
+		Limiters: lm,
+		Policies: pe,
+		Store:    store,
+		Audit:    audit.New(io.Discard),
+		Decider:  d,
+	})
+	return app, last
+}
+
+func doJSON(t *testing.T, app *fiber.App, method, url, body string) (int, string) {
+	t.Helper()
+	return doJSONAs(t, app, method, url, body, "")
+}
+
+// doJSONAs is doJSON with an X-Actor header, and a matching X-Request-ID.
+func doJSONAs(t *testing.T, app *fiber.App, method, url, body, actor string) (int, string) {
+	t.Helper()
+	req := httptest.NewRequest(method, url, strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	if actor != "" {
+		req.Header.Set("X-Actor", actor)
+		req.Header.Set("X-Request-ID", "req-"+actor)
+	}
+	resp, err := app.Test(req)
+	if err != nil {
+		t.Fatalf("%s %s: %v", method, url, err)
+	}
+	data, _ := io.ReadAll(resp.Body)
+	return resp.StatusCode, string(data)
+}
+
+func TestAdminLimiters_CRUD(t *testing.T) {
+	app, lm := newTestApp(t)
+
+	status, body := doJSON(t, app, "PUT", "/admin/limiters/%2Forders",
+		`{"type":"token-bucket","capacity":5,"refill_rate":1,"refill_every":2,"ttl":30}`)
+	if status != fiber.StatusOK {
+		t.Fatalf("PUT: expected 200, got %d %s", status, body)
+	}
+
+	status, body = doJSON(t, app, "GET", "/admin/limiters/%2Forders", "")
+	var got map[string]interface{}
+	json.Unmarshal([]byte(body), &got)
+	if status != fiber.StatusOK || got["name"] != "/orders" || got["type"] != "token-bucket" || got["capacity"] != 5.0 || got["refill_every"] != 2.0 {
+		t.Fatalf("GET: unexpected %d %s", status, body)
+	}
+
+	status, body = doJSON(t, app, "GET", "/admin/limiters", "")
+	if status != fiber.StatusOK || !strings.Contains(body, `"name":"/orders"`) {
+		t.Fatalf("list: unexpected %d %s", status, body)
+	}
+
+	status, body = doJSON(t, app, "PUT", "/admin/limiters/%2Forders",
+		`{"type":"fixed-window","limit":10,"window":60}`)
+	if status != fiber.StatusOK || !strings.Contains(body, `"type":"fixed-window"`) || !strings.Contains(body, `"limit":10`) {
+		t.Fatalf("PUT fixed-window: unexpected %d %s", status, body)
+	}
+	if l, _ := lm.GetLimiter("/orders"); !l.Check("c1").Allowed {
+		t.Fatal("expected fixed-window limiter to allow the first request")
+	}
+
+	status, body = doJSON(t, app, "DELETE", "/admin/limiters/%2Forders", "")
+	if status != fiber.StatusOK {
+		t.Fatalf("DELETE: expected 200, got %d %s", status, body)
+	}
+	if _, ok := lm.GetLimiter("/orders"); ok {
+		t.Fatal("expected limiter to be removed")
+	}
+
+	status, _ = doJSON(t, app, "DELETE", "/admin/limiters/%2Forders", "")
+	if status != fiber.StatusNotFound {
+		t.Fatalf("second DELETE: expected 404, got %d", status)
+	}
+}
+
+func TestAdminLimiters_Validation(t *testing.T) {
+	app, _ := newTestApp(t)
+
+	for _, body := range []string{
+		`{"name":"/a","type":"token-bucket","capacity":-1,"refill_rate":1,"refill_every":1}`,
+		`{"name":"/a","type":"token-bucket","capacity":5,"refill_rate":1,"refill_every":0}`,
+		`{"name":"/a","type":"fixed-window","limit":5,"window":0}`,
+		`{"name":"/a","type":"leaky-bucket"}`,
+		// "|" separates limiter names from client keys in the store
+		`{"name":"/a|b","type":"fixed-window","limit":5,"window":60}`,
+	} {
+		status, resp := doJSON(t, app, "POST", "/admin/limiters", body)
+		if status != fiber.StatusBadRequest || !strings.Contains(resp, "invalid configuration") {
+			t.Fatalf("expected 400 invalid configuration for %s, got %d %s", body, status, resp)
+		}
+	}
+}
+
+// Fiber reuses request buffers, so a name taken from the path must be copied
+// before it is stored; otherwise later requests overwrite stored names.
+func TestAdminLimiters_NamesSurviveLaterRequests(t *testing.T) {
+	app, lm := newTestApp(t)
+
+	for _, name := range []string{"aaaa", "bbbb", "cccc"} {
+		status, body := doJSON(t, app, "PUT", "/admin/limiters/"+name,
+			`{"type":"fixed-window","limit":10,"window":60}`)
+		if status != fiber.StatusOK {
+			t.Fatalf("PUT %s: %d %s", name, status, body)
+		}
+	}
+	for _, name := range []string{"aaaa", "bbbb", "cccc"} {
+		l, ok := lm.GetLimiter(name)
+		if !ok || l.Name() != name {
+			t.Fatalf("limiter %q lost or renamed: %v %v (have %v)", name, l, ok, lm.ListLimiters())
+		}
+	}
+}
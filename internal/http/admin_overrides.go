@@ -0,0 +1,129 @@
+package http
+
+import (
+	"errors"
+
+	"fibre_rate_limit_service/internal/audit"
+	"fibre_rate_limit_service/internal/decision"
+	"fibre_rate_limit_service/internal/limiters"
+	"fibre_rate_limit_service/internal/storage"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// AdminListOverridesHandler handles GET /admin/limiters/:name/overrides
+func AdminListOverridesHandler(c *fiber.Ctx, lm *limiters.Manager) error {
+	name, err := pathParam(c, "name")
+	if err != nil {
+		return JSONError(c, fiber.StatusBadRequest, "invalid limiter name")
+	}
+	if _, ok := lm.GetLimiter(name); !ok {
+		return JSONError(c, fiber.StatusNotFound, "limiter not found")
+	}
+
+	overrides := lm.Overrides(name)
+	if overrides == nil {
+		overrides = []limiters.Override{}
+	}
+	return c.JSON(overrides)
+}
+
+// AdminPutOverrideHandler handles PUT /admin/limiters/:name/overrides/:key.
+// The body holds the limiter type's spec fields, e.g. {"capacity": 100,
+// "refill_rate": 10, "refill_every": 1}; a key ending in "*" matches every
+// client ID with that prefix.
+func AdminPutOverrideHandler(c *fiber.Ctx, lm *limiters.Manager, d *decision.Decider, store *storage.ShardedMap, al *audit.Log) error {
+	name, key, err := overrideParams(c)
+	if err != nil {
+		return JSONError(c, fiber.StatusBadRequest, "invalid limiter name or key")
+	}
+
+	def, ok := lm.Definition(name)
+	if !ok {
+		return JSONError(c, fiber.StatusNotFound, "limiter not found")
+	}
+
+	o, err := limiters.DecodeOverride(def.Type, c.Body())
+	if err != nil {
+		return limiterError(c, err)
+	}
+	o.Key = key
+
+	// The override was decoded for def.Type; install it only if the limiter
+	// still has that type.
+	var before interface{}
+	err = d.Update(func() error {
+		cur, ok := lm.Definition(name)
+		switch {
+		case !ok:
+			return errLimiterNotFound
+		case cur.Type != def.Type:
+			return errLimiterChanged
+		}
+		before = findOverride(cur.Overrides, key)
+		return lm.SetOverride(name, o, store)
+	})
+	switch {
+	case errors.Is(err, errLimiterNotFound):
+		return JSONError(c, fiber.StatusNotFound, "limiter not found")
+	case errors.Is(err, errLimiterChanged):
+		return JSONError(c, fiber.StatusConflict, err.Error())
+	case err != nil:
+		return limiterError(c, err)
+	}
+
+	recordAction(c, al, "override.set", name+"/"+key, before, o)
+	return c.JSON(o)
+}
+
+// errLimiterChanged reports a limiter whose type changed while an override
+// for it was being decoded.
+var errLimiterChanged = errors.New("limiter type changed; retry")
+
+// AdminDeleteOverrideHandler handles DELETE /admin/limiters/:name/overrides/:key
+func AdminDeleteOverrideHandler(c *fiber.Ctx, lm *limiters.Manager, d *decision.Decider, al *audit.Log) error {
+	name, key, err := overrideParams(c)
+	if err != nil {
+		return JSONError(c, fiber.StatusBadRequest, "invalid limiter name or key")
+	}
+
+	var (
+		before  interface{}
+		removed bool
+	)
+	d.Update(func() error {
+		before = findOverride(lm.Overrides(name), key)
+		removed = lm.RemoveOverride(name, key)
+		return nil
+	})
+	if !removed {
+		return JSONError(c, fiber.StatusNotFound, "override not found")
+	}
+
+	recordAction(c, al, "override.delete", name+"/"+key, before, nil)
+	return c.JSON(fiber.Map{
+		"message": "override deleted successfully",
+	})
+}
+
+func overrideParams(c *fiber.Ctx) (string, string, error) {
+	name, err := pathParam(c, "name")
+	if err != nil {
+		return "", "", err
+	}
+	key, err := pathParam(c, "key")
+	if err != nil {
+		return "", "", err
+	}
+	return name, key, nil
+}
+
+// findOverride returns the override for key, or nil so audit entries omit it.
+func findOverride(overrides []limiters.Override, key string) interface{} {
+	for _, o := range overrides {
+		if o.Key == key {
+			return o
+		}
+	}
+	return nil
+}
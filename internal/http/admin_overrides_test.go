@@ -0,0 +1,86 @@
+package http
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func TestAdminOverrides(t *testing.T) {
+	app, lm := newTestApp(t)
+
+	if status, body := doJSON(t, app, "PUT", "/admin/limiters/%2Forders/overrides/acme", `{"limit":5,"window":60}`); status != fiber.StatusNotFound {
+		t.Fatalf("unknown limiter: expected 404, got %d %s", status, body)
+	}
+	if status, _ := doJSON(t, app, "GET", "/admin/limiters/%2Forders/overrides", ""); status != fiber.StatusNotFound {
+		t.Fatalf("list on unknown limiter: expected 404, got %d", status)
+	}
+
+	if status, body := doJSON(t, app, "PUT", "/admin/limiters/%2Forders", `{"type":"fixed-window","limit":1,"window":60}`); status != fiber.StatusOK {
+		t.Fatalf("PUT limiter: %d %s", status, body)
+	}
+
+	for _, tc := range []struct {
+		name, url, body string
+	}{
+		{"bad json", "/admin/limiters/%2Forders/overrides/acme", `{`},
+		{"no settings", "/admin/limiters/%2Forders/overrides/acme", `{}`},
+		{"invalid settings", "/admin/limiters/%2Forders/overrides/acme", `{"limit":0,"window":60}`},
+		{"bare wildcard", "/admin/limiters/%2Forders/overrides/*", `{"limit":5,"window":60}`},
+	} {
+		if status, body := doJSON(t, app, "PUT", tc.url, tc.body); status != fiber.StatusBadRequest {
+			t.Errorf("%s: expected 400, got %d %s", tc.name, status, body)
+		}
+	}
+
+	status, body := doJSONAs(t, app, "PUT", "/admin/limiters/%2Forders/overrides/acme:*", `{"limit":3,"window":60}`, "alice")
+	if status != fiber.StatusOK || !strings.Contains(body, `"key":"acme:*"`) || !strings.Contains(body, `"limit":3`) {
+		t.Fatalf("PUT prefix override: %d %s", status, body)
+	}
+	// The prefix override applies to every acme key and to no others
+	for key, want := range map[string]int{"acme:1": 3, "acme:2": 3, "globex:1": 1} {
+		l, _, _ := lm.Resolve("/orders", key)
+		if got := l.Check(key).Limit; got != want {
+			t.Errorf("%s: limit %d, want %d", key, got, want)
+		}
+	}
+
+	status, body = doJSON(t, app, "GET", "/admin/limiters/%2Forders/overrides", "")
+	var list []map[string]interface{}
+	if err := json.Unmarshal([]byte(body), &list); err != nil || status != fiber.StatusOK || len(list) != 1 || list[0]["key"] != "acme:*" {
+		t.Fatalf("GET overrides: %d %s", status, body)
+	}
+
+	if status, _ := doJSON(t, app, "DELETE", "/admin/limiters/%2Forders/overrides/globex:*", ""); status != fiber.StatusNotFound {
+		t.Fatalf("DELETE unknown override: expected 404, got %d", status)
+	}
+	if status, body := doJSONAs(t, app, "DELETE", "/admin/limiters/%2Forders/overrides/acme:*", "", "bob"); status != fiber.StatusOK {
+		t.Fatalf("DELETE override: %d %s", status, body)
+	}
+	if l, _, _ := lm.Resolve("/orders", "acme:9"); l.Check("acme:9").Limit != 1 {
+		t.Fatal("override still applies after DELETE")
+	}
+
+	_, body = doJSON(t, app, "GET", "/admin/audit", "")
+	var entries []struct {
+		Action, Target, Actor string
+		Before, After         map[string]interface{}
+	}
+	if err := json.Unmarshal([]byte(body), &entries); err != nil {
+		t.Fatalf("audit: %s", body)
+	}
+	var set, deleted bool
+	for _, e := range entries {
+		switch {
+		case e.Action == "override.set" && e.Target == "/orders/acme:*" && e.Actor == "alice" && e.Before == nil && e.After["limit"] == 3.0:
+			set = true
+		case e.Action == "override.delete" && e.Target == "/orders/acme:*" && e.Actor == "bob" && e.Before["limit"] == 3.0 && e.After == nil:
+			deleted = true
+		}
+	}
+	if !set || !deleted {
+		t.Fatalf("missing override audit entries: %s", body)
+	}
+}
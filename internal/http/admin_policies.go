@@ -1,36 +1,232 @@
 package http
 
 import (
-	//"encoding/json"
+	"encoding/json"
+
+	"fibre_rate_limit_service/internal/audit"
+	"fibre_rate_limit_service/internal/config"
+	"fibre_rate_limit_service/internal/decision"
 	"fibre_rate_limit_service/internal/policies"
 
 	"github.com/gofiber/fiber/v2"
 )
 
-// PolicyRuleRequest represents a single policy rule
+// PolicyRequest represents a single policy rule
 type PolicyRequest struct {
-    Route  string `json:"route"`
-    Header string `json:"header"` // single header name
-    Value  string `json:"value"`  // value that header must match
+	Route  string `json:"route"`
+	ID     string `json:"id,omitempty"` // optional; assigned when empty
+	Header string `json:"header"`       // single header name
+	Value  string `json:"value"`        // value that header must match
+	// Mode is "enforce" (the default) or "shadow", which only records
+	// requests the rule would reject.
+	Mode config.Mode `json:"mode,omitempty"`
 }
 
-
 // AdminPoliciesHandler handles POST /admin/policies
-func AdminPoliciesHandler(c *fiber.Ctx, pe *policies.Evaluator) error {
-    var req PolicyRequest
-    if err := c.BodyParser(&req); err != nil {
-        return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-            "error": "invalid request body",
-        })
-    }
-
-    // Add rule to Evaluator
-    pe.AddRule(req.Route, policies.Rule{
-        Header: req.Header,
-        Value:  req.Value,
-    })
-
-    return c.JSON(fiber.Map{
-        "message": "policy added/updated successfully",
-    })
+func AdminPoliciesHandler(c *fiber.Ctx, pe *policies.Evaluator, d *decision.Decider, al *audit.Log) error {
+	var req PolicyRequest
+	if err := c.BodyParser(&req); err != nil {
+		return JSONError(c, fiber.StatusBadRequest, "invalid request body")
+	}
+	if req.Route == "" || req.Header == "" {
+		return JSONError(c, fiber.StatusBadRequest, "route and header are required")
+	}
+	if err := req.Mode.Validate(); err != nil {
+		return JSONError(c, fiber.StatusBadRequest, err.Error())
+	}
+
+	// Add rule to Evaluator
+	var (
+		before, after []policies.Rule
+		rule          policies.Rule
+	)
+	d.Update(func() error {
+		before = pe.Rules(req.Route)
+		rule = pe.AddRule(req.Route, policies.Rule{
+			ID:     req.ID,
+			Header: req.Header,
+			Value:  req.Value,
+			Mode:   req.Mode,
+		})
+		after = pe.Rules(req.Route)
+		return nil
+	})
+	recordAction(c, al, "policy.add", req.Route, rulesOrNil(before), after)
+
+	return c.JSON(fiber.Map{
+		"message": "policy added/updated successfully",
+		"rule":    rule,
+	})
+}
+
+// AdminListPoliciesHandler handles GET /admin/policies
+func AdminListPoliciesHandler(c *fiber.Ctx, pe *policies.Evaluator) error {
+	out := make(map[string][]policies.Rule)
+	for _, route := range pe.Routes() {
+		out[route] = pe.Rules(route)
+	}
+	return c.JSON(out)
+}
+
+// AdminGetPoliciesHandler handles GET /admin/policies/:route
+func AdminGetPoliciesHandler(c *fiber.Ctx, pe *policies.Evaluator) error {
+	route, err := pathParam(c, "route")
+	if err != nil {
+		return JSONError(c, fiber.StatusBadRequest, "invalid route")
+	}
+
+	rules := pe.Rules(route)
+	if rules == nil {
+		rules = []policies.Rule{}
+	}
+	return c.JSON(rules)
+}
+
+// AdminReplacePoliciesHandler handles PUT /admin/policies/:route, atomically
+// swapping the route's entire rule set for the JSON array in the body.
+func AdminReplacePoliciesHandler(c *fiber.Ctx, pe *policies.Evaluator, d *decision.Decider, al *audit.Log) error {
+	route, err := pathParam(c, "route")
+	if err != nil {
+		return JSONError(c, fiber.StatusBadRequest, "invalid route")
+	}
+
+	var rules []policies.Rule
+	if err := json.Unmarshal(c.Body(), &rules); err != nil {
+		return JSONError(c, fiber.StatusBadRequest, "invalid request body")
+	}
+	seen := make(map[string]bool, len(rules))
+	for _, r := range rules {
+		if r.Header == "" {
+			return JSONError(c, fiber.StatusBadRequest, "header is required")
+		}
+		if err := r.Mode.Validate(); err != nil {
+			return JSONError(c, fiber.StatusBadRequest, err.Error())
+		}
+		if r.ID != "" && seen[r.ID] {
+			return JSONError(c, fiber.StatusBadRequest, "duplicate rule id "+r.ID)
+		}
+		seen[r.ID] = true
+	}
+
+	var before []policies.Rule
+	d.Update(func() error {
+		before = pe.Rules(route)
+		rules = pe.SetRules(route, rules)
+		return nil
+	})
+	recordAction(c, al, "policy.replace", route, rulesOrNil(before), rulesOrNil(rules))
+	if rules == nil {
+		rules = []policies.Rule{}
+	}
+	return c.JSON(rules)
+}
+
+// AdminDeletePoliciesHandler handles DELETE /admin/policies/:route
+func AdminDeletePoliciesHandler(c *fiber.Ctx, pe *policies.Evaluator, d *decision.Decider, al *audit.Log) error {
+	route, err := pathParam(c, "route")
+	if err != nil {
+		return JSONError(c, fiber.StatusBadRequest, "invalid route")
+	}
+
+	var before []policies.Rule
+	d.Update(func() error {
+		before = pe.Rules(route)
+		pe.SetRules(route, nil)
+		return nil
+	})
+	recordAction(c, al, "policy.delete", route, rulesOrNil(before), nil)
+	return c.JSON(fiber.Map{
+		"message": "policies deleted successfully",
+	})
+}
+
+// AdminGetPolicyRuleHandler handles GET /admin/policies/:route/rules/:id
+func AdminGetPolicyRuleHandler(c *fiber.Ctx, pe *policies.Evaluator) error {
+	route, id, err := policyRuleParams(c)
+	if err != nil {
+		return JSONError(c, fiber.StatusBadRequest, "invalid route or rule id")
+	}
+
+	rule, ok := pe.GetRule(route, id)
+	if !ok {
+		return JSONError(c, fiber.StatusNotFound, "rule not found")
+	}
+	return c.JSON(rule)
+}
+
+// AdminPutPolicyRuleHandler handles PUT /admin/policies/:route/rules/:id
+func AdminPutPolicyRuleHandler(c *fiber.Ctx, pe *policies.Evaluator, d *decision.Decider, al *audit.Log) error {
+	route, id, err := policyRuleParams(c)
+	if err != nil {
+		return JSONError(c, fiber.StatusBadRequest, "invalid route or rule id")
+	}
+
+	var rule policies.Rule
+	if err := json.Unmarshal(c.Body(), &rule); err != nil {
+		return JSONError(c, fiber.StatusBadRequest, "invalid request body")
+	}
+	if rule.Header == "" {
+		return JSONError(c, fiber.StatusBadRequest, "header is required")
+	}
+	if err := rule.Mode.Validate(); err != nil {
+		return JSONError(c, fiber.StatusBadRequest, err.Error())
+	}
+
+	var (
+		before   policies.Rule
+		replaced bool
+	)
+	d.Update(func() error {
+		before, _ = pe.GetRule(route, id)
+		replaced = pe.ReplaceRule(route, id, rule)
+		return nil
+	})
+	if !replaced {
+		return JSONError(c, fiber.StatusNotFound, "rule not found")
+	}
+	rule.ID = id
+	recordAction(c, al, "policy.rule.set", route+"/"+id, before, rule)
+	return c.JSON(rule)
+}
+
+// AdminDeletePolicyRuleHandler handles DELETE /admin/policies/:route/rules/:id
+func AdminDeletePolicyRuleHandler(c *fiber.Ctx, pe *policies.Evaluator, d *decision.Decider, al *audit.Log) error {
+	route, id, err := policyRuleParams(c)
+	if err != nil {
+		return JSONError(c, fiber.StatusBadRequest, "invalid route or rule id")
+	}
+
+	var (
+		before  policies.Rule
+		deleted bool
+	)
+	d.Update(func() error {
+		before, _ = pe.GetRule(route, id)
+		deleted = pe.DeleteRule(route, id)
+		return nil
+	})
+	if !deleted {
+		return JSONError(c, fiber.StatusNotFound, "rule not found")
+	}
+	recordAction(c, al, "policy.rule.delete", route+"/"+id, before, nil)
+	return c.JSON(fiber.Map{
+		"message": "rule deleted successfully",
+	})
+}
+
+// rulesOrNil leaves empty rule sets out of audit entries.
+func rulesOrNil(rules []policies.Rule) interface{} {
+	return orNil(rules, len(rules) > 0)
+}
+
+func policyRuleParams(c *fiber.Ctx) (string, string, error) {
+	route, err := pathParam(c, "route")
+	if err != nil {
+		return "", "", err
+	}
+	id, err := pathParam(c, "id")
+	if err != nil {
+		return "", "", err
+	}
+	return route, id, nil
 }
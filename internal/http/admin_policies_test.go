@@ -0,0 +1,87 @@
+package http
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"fibre_rate_limit_service/internal/policies"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func TestAdminPolicies_CRUD(t *testing.T) {
+	app, _ := newTestApp(t)
+
+	// Re-posting the same header updates the rule instead of duplicating it.
+	var added struct {
+		Rule policies.Rule `json:"rule"`
+	}
+	for _, value := range []string{"123", "456"} {
+		status, body := doJSON(t, app, "POST", "/admin/policies",
+			`{"route":"/orders","header":"X-Secret","value":"`+value+`"}`)
+		if status != fiber.StatusOK {
+			t.Fatalf("POST: expected 200, got %d %s", status, body)
+		}
+		json.Unmarshal([]byte(body), &added)
+	}
+
+	status, body := doJSON(t, app, "GET", "/admin/policies/%2Forders", "")
+	var rules []policies.Rule
+	json.Unmarshal([]byte(body), &rules)
+	if status != fiber.StatusOK || len(rules) != 1 || rules[0].Value != "456" || rules[0].ID != added.Rule.ID {
+		t.Fatalf("GET route: unexpected %d %s", status, body)
+	}
+
+	ruleURL := "/admin/policies/%2Forders/rules/" + added.Rule.ID
+	status, body = doJSON(t, app, "PUT", ruleURL, `{"header":"X-Secret","value":"789"}`)
+	if status != fiber.StatusOK || !strings.Contains(body, `"value":"789"`) {
+		t.Fatalf("PUT rule: unexpected %d %s", status, body)
+	}
+
+	status, body = doJSON(t, app, "PUT", "/admin/policies/%2Forders",
+		`[{"id":"tenant","header":"X-Tenant","value":"acme"},{"header":"X-Env","value":"prod"}]`)
+	json.Unmarshal([]byte(body), &rules)
+	if status != fiber.StatusOK || len(rules) != 2 || rules[0].ID != "tenant" || rules[1].ID == "" {
+		t.Fatalf("bulk replace: unexpected %d %s", status, body)
+	}
+
+	if status, _ = doJSON(t, app, "GET", ruleURL, ""); status != fiber.StatusNotFound {
+		t.Fatalf("expected replaced rule to be gone, got %d", status)
+	}
+
+	if status, _ = doJSON(t, app, "DELETE", "/admin/policies/%2Forders/rules/tenant", ""); status != fiber.StatusOK {
+		t.Fatalf("DELETE rule: expected 200, got %d", status)
+	}
+
+	status, body = doJSON(t, app, "GET", "/admin/policies", "")
+	if status != fiber.StatusOK || strings.Contains(body, "X-Tenant") || !strings.Contains(body, "X-Env") {
+		t.Fatalf("list: unexpected %d %s", status, body)
+	}
+}
+
+// Routes taken from the path are stored as map keys, so they must not alias
+// Fiber's reused request buffers.
+func TestAdminPolicies_RoutesSurviveLaterRequests(t *testing.T) {
+	app, _ := newTestApp(t)
+
+	for _, route := range []string{"aaaa", "bbbb", "cccc"} {
+		status, body := doJSON(t, app, "PUT", "/admin/policies/"+route,
+			`[{"header":"X-Secret","value":"`+route+`"}]`)
+		if status != fiber.StatusOK {
+			t.Fatalf("PUT %s: %d %s", route, status, body)
+		}
+	}
+	for _, route := range []string{"aaaa", "bbbb", "cccc"} {
+		status, body := doJSON(t, app, "GET", "/admin/policies/"+route, "")
+		if status != fiber.StatusOK || !strings.Contains(body, `"value":"`+route+`"`) {
+			t.Fatalf("GET %s: %d %s", route, status, body)
+		}
+	}
+	_, body := doJSON(t, app, "GET", "/admin/policies", "")
+	var all map[string][]policies.Rule
+	json.Unmarshal([]byte(body), &all)
+	if len(all) != 3 || all["aaaa"] == nil || all["bbbb"] == nil || all["cccc"] == nil {
+		t.Fatalf("routes corrupted: %s", body)
+	}
+}
@@ -0,0 +1,75 @@
+package http
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"time"
+
+	"fibre_rate_limit_service/internal/stream"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/utils"
+)
+
+// streamKeepAlive is how often an idle stream sends a comment, so proxies
+// keep the connection open and closed clients are noticed.
+const streamKeepAlive = 15 * time.Second
+
+// AdminStreamHandler handles GET /admin/stream
+//
+// It streams decisions as Server-Sent Events ("event: decision", with the
+// stream.Event as JSON data). Query parameters filter the stream: route,
+// key_prefix, denied_only=true, and sample (a fraction in (0, 1]).
+func AdminStreamHandler(c *fiber.Ctx, hub *stream.Hub) error {
+	f := stream.Filter{
+		Route:      utils.CopyString(c.Query("route")),
+		KeyPrefix:  utils.CopyString(c.Query("key_prefix")),
+		DeniedOnly: c.QueryBool("denied_only"),
+	}
+	if v := c.Query("sample"); v != "" {
+		var err error
+		if f.Sample, err = strconv.ParseFloat(v, 64); err != nil || f.Sample <= 0 || f.Sample > 1 {
+			return JSONError(c, fiber.StatusBadRequest, "sample must be a number in (0, 1]")
+		}
+	}
+
+	sub := hub.Subscribe(f)
+	if sub == nil {
+		return JSONError(c, fiber.StatusServiceUnavailable, "server is shutting down")
+	}
+
+	c.Set(fiber.HeaderContentType, "text/event-stream")
+	c.Set(fiber.HeaderCacheControl, "no-cache")
+	c.Set("X-Accel-Buffering", "no") // stop NGINX buffering the stream
+
+	// The writer runs after the handler returns, so it must not touch c.
+	c.Context().SetBodyStreamWriter(func(w *bufio.Writer) {
+		defer sub.Close()
+
+		keepAlive := time.NewTicker(streamKeepAlive)
+		defer keepAlive.Stop()
+
+		fmt.Fprint(w, ": connected\n\n")
+		for {
+			if err := w.Flush(); err != nil {
+				return // client went away
+			}
+			select {
+			case e, ok := <-sub.C:
+				if !ok {
+					return
+				}
+				data, err := json.Marshal(e)
+				if err != nil {
+					continue
+				}
+				fmt.Fprintf(w, "event: decision\ndata: %s\n\n", data)
+			case <-keepAlive.C:
+				fmt.Fprintf(w, ": keep-alive dropped=%d\n\n", sub.Dropped())
+			}
+		}
+	})
+	return nil
+}
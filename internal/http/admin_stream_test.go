@@ -0,0 +1,85 @@
+package http
+
+import (
+	"bufio"
+	"encoding/json"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"fibre_rate_limit_service/internal/audit"
+	"fibre_rate_limit_service/internal/decision"
+	"fibre_rate_limit_service/internal/limiters"
+	"fibre_rate_limit_service/internal/policies"
+	"fibre_rate_limit_service/internal/storage"
+	"fibre_rate_limit_service/internal/stream"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func TestAdminStream(t *testing.T) {
+	store := storage.NewShardedMap(4, time.Minute, time.Minute)
+	defer store.Close()
+
+	lm := limiters.NewManager()
+	lm.SetLimiter("/check", limiters.NewTokenBucket(limiters.TokenBucketConfig{
+		Name:        "/check",
+		Capacity:    1,
+		RefillRate:  1,
+		RefillEvery: time.Hour,
+	}, store))
+	pe := policies.NewEvaluator()
+	d := decision.NewDecider(lm, pe)
+	hub := stream.NewHub()
+	d.Observe(hub.Observe)
+
+	app := fiber.New(fiber.Config{DisableStartupMessage: true})
+	SetupRouter(app, Services{Limiters: lm, Policies: pe, Store: store, Audit: audit.New(io.Discard), Decider: d, Stream: hub})
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	go app.Listener(ln)
+	defer app.Shutdown()
+	defer hub.Close() // ends the stream, which Shutdown would wait for
+	base := "http://" + ln.Addr().String()
+
+	resp, err := http.Get(base + "/admin/stream?denied_only=true&key_prefix=team-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	if ct := resp.Header.Get("Content-Type"); ct != "text/event-stream" {
+		t.Fatalf("content type %q", ct)
+	}
+
+	for _, key := range []string{"team-a", "team-a", "other", "other"} {
+		req, _ := http.NewRequest("POST", base+"/check", nil)
+		req.Header.Set("X-Client-ID", key)
+		r, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		r.Body.Close()
+	}
+
+	lines := bufio.NewScanner(resp.Body)
+	var data string
+	for lines.Scan() {
+		if d, ok := strings.CutPrefix(lines.Text(), "data: "); ok {
+			data = d
+			break
+		}
+	}
+	var e stream.Event
+	if err := json.Unmarshal([]byte(data), &e); err != nil {
+		t.Fatalf("decode %q: %v", data, err)
+	}
+	if e.Key != "team-a" || e.Allowed || e.Limiter != "/check" || e.Remaining == nil {
+		t.Errorf("unexpected event %+v", e)
+	}
+}
@@ -0,0 +1,48 @@
+package http
+
+import (
+	"strconv"
+	"time"
+
+	"fibre_rate_limit_service/internal/hotkeys"
+	"fibre_rate_limit_service/internal/limiters"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// Defaults for GET /admin/limiters/:name/top
+const (
+	defaultTopWindow = 5 * time.Minute
+	defaultTopN      = 10
+)
+
+// AdminTopKeysHandler handles GET /admin/limiters/:name/top
+//
+// Query parameters: window (a duration, default 5m, capped at the tracked
+// history) and n (default 10). The response lists the most active and most
+// denied keys with estimated counts; a key's true count lies between
+// count-error and count.
+func AdminTopKeysHandler(c *fiber.Ctx, lm *limiters.Manager, hk *hotkeys.Tracker) error {
+	name, err := pathParam(c, "name")
+	if err != nil {
+		return JSONError(c, fiber.StatusBadRequest, errInvalidParam.Error())
+	}
+	if _, ok := lm.GetLimiter(name); !ok {
+		return JSONError(c, fiber.StatusNotFound, errLimiterNotFound.Error())
+	}
+
+	window := defaultTopWindow
+	if v := c.Query("window"); v != "" {
+		if window, err = time.ParseDuration(v); err != nil || window <= 0 {
+			return JSONError(c, fiber.StatusBadRequest, "window must be a positive duration, e.g. 5m")
+		}
+	}
+	n := defaultTopN
+	if v := c.Query("n"); v != "" {
+		if n, err = strconv.Atoi(v); err != nil || n <= 0 {
+			return JSONError(c, fiber.StatusBadRequest, "n must be a positive integer")
+		}
+	}
+
+	return c.JSON(hk.Top(name, window, n))
+}
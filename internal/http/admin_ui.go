@@ -0,0 +1,36 @@
+package http
+
+import (
+	"embed"
+	"path"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// uiFiles is the admin dashboard: a static page whose script calls the admin
+// API. Only files under ui/ are embedded, so no request path reaches others.
+//
+//go:embed ui
+var uiFiles embed.FS
+
+// AdminUIHandler handles GET /admin/ui and the files under it
+//
+// The page holds no data and its script sends the operator's bearer token
+// with every admin API call, so it is served without authentication; the API
+// still checks each request's role.
+func AdminUIHandler(c *fiber.Ctx) error {
+	name := c.Params("*")
+	if name == "" {
+		name = "index.html"
+	}
+	data, err := uiFiles.ReadFile(path.Join("ui", name))
+	if err != nil {
+		return JSONError(c, fiber.StatusNotFound, "not found")
+	}
+
+	c.Type(path.Ext(name))
+	c.Set(fiber.HeaderCacheControl, "no-cache")
+	c.Set(fiber.HeaderContentSecurityPolicy, "default-src 'self'; frame-ancestors 'none'")
+	c.Set(fiber.HeaderXContentTypeOptions, "nosniff")
+	return c.Send(data)
+}
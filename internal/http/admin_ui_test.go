@@ -0,0 +1,75 @@
+package http
+
+import (
+	"io"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"fibre_rate_limit_service/internal/audit"
+	"fibre_rate_limit_service/internal/http/middleware"
+	"fibre_rate_limit_service/internal/limiters"
+	"fibre_rate_limit_service/internal/policies"
+	"fibre_rate_limit_service/internal/storage"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func TestAdminUI(t *testing.T) {
+	authPath := filepath.Join(t.TempDir(), "auth.yaml")
+	if err := os.WriteFile(authPath, []byte("tokens:\n  - {name: alice, role: admin, token: secret}\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	auth, err := middleware.LoadAdminAuth(authPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	store := storage.NewShardedMap(4, time.Minute, time.Minute)
+	defer store.Close()
+
+	app := fiber.New()
+	SetupRouter(app, Services{
+		Limiters:  limiters.NewManager(),
+		Policies:  policies.NewEvaluator(),
+		Store:     store,
+		Audit:     audit.New(io.Discard),
+		AdminAuth: auth,
+	})
+
+	get := func(url string) (int, string, string) {
+		t.Helper()
+		resp, err := app.Test(httptest.NewRequest("GET", url, nil))
+		if err != nil {
+			t.Fatalf("GET %s: %v", url, err)
+		}
+		body, _ := io.ReadAll(resp.Body)
+		return resp.StatusCode, resp.Header.Get("Content-Type"), string(body)
+	}
+
+	// The dashboard loads without credentials...
+	for url, wantType := range map[string]string{
+		"/admin/ui":           "text/html",
+		"/admin/ui/":          "text/html",
+		"/admin/ui/app.js":    "javascript",
+		"/admin/ui/style.css": "text/css",
+	} {
+		status, typ, body := get(url)
+		if status != fiber.StatusOK || !strings.Contains(typ, wantType) || body == "" {
+			t.Errorf("GET %s: %d %q", url, status, typ)
+		}
+	}
+	if status, _, _ := get("/admin/ui/missing.js"); status != fiber.StatusNotFound {
+		t.Errorf("missing file: %d, want 404", status)
+	}
+	if status, _, _ := get("/admin/ui/../admin_ui.go"); status != fiber.StatusNotFound {
+		t.Errorf("path outside ui/: %d, want 404", status)
+	}
+
+	// ...but the API it calls still requires them
+	if status, _, _ := get("/admin/limiters"); status != fiber.StatusUnauthorized {
+		t.Errorf("GET /admin/limiters without token: %d, want 401", status)
+	}
+}
@@ -0,0 +1,89 @@
+package http
+
+import (
+	"strconv"
+	"time"
+
+	"fibre_rate_limit_service/internal/audit"
+	"fibre_rate_limit_service/internal/config"
+	"fibre_rate_limit_service/internal/http/middleware"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/utils"
+)
+
+// recordAction writes an admin action to the audit log, if one is configured.
+func recordAction(c *fiber.Ctx, al *audit.Log, action, target string, before, after interface{}) {
+	if al == nil {
+		return
+	}
+	if err := al.Record(audit.Entry{
+		Action:    action,
+		Target:    target,
+		Actor:     actor(c),
+		SourceIP:  utils.CopyString(c.IP()),
+		RequestID: middleware.RequestID(c),
+		Before:    before,
+		After:     after,
+	}); err != nil {
+		config.Logger.Error("audit log error", "err", err)
+	}
+}
+
+// Bounds on GET /admin/audit results
+const (
+	defaultAuditLimit = 1000
+	maxAuditLimit     = 10000
+)
+
+// AdminAuditHandler handles GET /admin/audit. Optional query parameters:
+// since and until (RFC 3339), actor, action and limit (most recent N,
+// default 1000, at most 10000).
+func AdminAuditHandler(c *fiber.Ctx, al *audit.Log) error {
+	if al == nil {
+		return c.JSON([]audit.Entry{})
+	}
+
+	var f audit.Filter
+	for param, dst := range map[string]*time.Time{"since": &f.Since, "until": &f.Until} {
+		if v := c.Query(param); v != "" {
+			t, err := time.Parse(time.RFC3339, v)
+			if err != nil {
+				return JSONError(c, fiber.StatusBadRequest, param+" must be an RFC 3339 time")
+			}
+			*dst = t
+		}
+	}
+	f.Actor = c.Query("actor")
+	f.Action = c.Query("action")
+	if f.Limit = c.QueryInt("limit", defaultAuditLimit); f.Limit <= 0 || f.Limit > maxAuditLimit {
+		return JSONError(c, fiber.StatusBadRequest, "limit must be between 1 and "+strconv.Itoa(maxAuditLimit))
+	}
+
+	entries, err := al.Query(f)
+	if err != nil {
+		return JSONError(c, fiber.StatusInternalServerError, "failed to read audit log")
+	}
+	return c.JSON(entries)
+}
+
+// orNil returns v when ok, and an untyped nil otherwise, so missing "before"
+// or "after" values are left out of audit entries.
+func orNil(v interface{}, ok bool) interface{} {
+	if !ok {
+		return nil
+	}
+	return v
+}
+
+// actor names who made an admin request: the authenticated principal or,
+// when admin auth is off, the X-Actor header or the caller's IP address.
+func actor(c *fiber.Ctx) string {
+	if p, ok := middleware.PrincipalFrom(c); ok {
+		return p.Name
+	}
+	if a := c.Get("X-Actor"); a != "" {
+		return utils.CopyString(a)
+	}
+	return utils.CopyString(c.IP())
+}
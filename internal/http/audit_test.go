@@ -0,0 +1,101 @@
+package http
+
+import (
+	"encoding/json"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"fibre_rate_limit_service/internal/audit"
+	"fibre_rate_limit_service/internal/decision"
+	"fibre_rate_limit_service/internal/limiters"
+	"fibre_rate_limit_service/internal/policies"
+	"fibre_rate_limit_service/internal/storage"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func TestAdminAudit_RecordsMutations(t *testing.T) {
+	app, _ := newTestApp(t)
+
+	req := func(method, url, body, who string) {
+		t.Helper()
+		status, resp := doJSONAs(t, app, method, url, body, who)
+		if status != fiber.StatusOK {
+			t.Fatalf("%s %s: unexpected %d %s", method, url, status, resp)
+		}
+	}
+	req("PUT", "/admin/limiters/%2Fa", `{"type":"fixed-window","limit":5,"window":60}`, "alice")
+	req("PUT", "/admin/limiters/%2Fa", `{"type":"fixed-window","limit":10,"window":60}`, "bob")
+	req("POST", "/admin/policies", `{"route":"/a","header":"X-Secret","value":"1"}`, "alice")
+
+	status, body := doJSON(t, app, "GET", "/admin/audit?actor=bob", "")
+	var entries []struct {
+		Action, Target, Actor string
+		RequestID             string `json:"request_id"`
+		Before, After         map[string]interface{}
+	}
+	if err := json.Unmarshal([]byte(body), &entries); err != nil || status != fiber.StatusOK {
+		t.Fatalf("unexpected %d %s", status, body)
+	}
+	if len(entries) != 1 {
+		t.Fatalf("expected one entry for bob, got %s", body)
+	}
+	e := entries[0]
+	if e.Action != "limiter.set" || e.Target != "/a" || e.Before["limit"] != 5.0 || e.After["limit"] != 10.0 || e.RequestID != "req-bob" {
+		t.Fatalf("unexpected entry %+v", e)
+	}
+
+	_, body = doJSON(t, app, "GET", "/admin/audit?action=policy.add", "")
+	var policyEntries []map[string]interface{}
+	if err := json.Unmarshal([]byte(body), &policyEntries); err != nil || len(policyEntries) != 1 || policyEntries[0]["actor"] != "alice" {
+		t.Fatalf("unexpected policy entries %s", body)
+	}
+
+	if status, _ := doJSON(t, app, "GET", "/admin/audit?since=yesterday", ""); status != fiber.StatusBadRequest {
+		t.Fatalf("expected 400 for a bad time, got %d", status)
+	}
+}
+
+// blockingWriter holds every write until release is closed.
+type blockingWriter struct {
+	writing chan struct{}
+	release chan struct{}
+}
+
+func (w blockingWriter) Write(p []byte) (int, error) {
+	w.writing <- struct{}{}
+	<-w.release
+	return len(p), nil
+}
+
+// Audit entries are written after the decider's update lock is released, so
+// a slow audit log doesn't hold up decisions.
+func TestAdminAudit_WrittenOutsideUpdateLock(t *testing.T) {
+	store := storage.NewShardedMap(4, time.Minute, time.Minute)
+	defer store.Close()
+	lm, pe := limiters.NewManager(), policies.NewEvaluator()
+	d := decision.NewDecider(lm, pe)
+	w := blockingWriter{writing: make(chan struct{}), release: make(chan struct{})}
+	app := fiber.New()
+	SetupRouter(app, Services{Limiters: lm, Policies: pe, Decider: d, Store: store, Audit: audit.New(w)})
+
+	go func() {
+		req := httptest.NewRequest("PUT", "/admin/limiters/%2Fa", strings.NewReader(`{"type":"fixed-window","limit":5,"window":60}`))
+		app.Test(req, -1)
+	}()
+	<-w.writing
+	defer close(w.release)
+
+	decided := make(chan decision.Decision)
+	go func() { decided <- d.Decide(decision.Request{ClientID: "c1", Route: "/a"}) }()
+	select {
+	case res := <-decided:
+		if !res.Limited {
+			t.Fatalf("decision during the audit write: %+v, want the new limiter", res)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("decision blocked behind the audit write")
+	}
+}
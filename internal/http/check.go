@@ -1,56 +1,49 @@
 package http
 
 import (
-	"fibre_rate_limit_service/internal/limiters"
-	"fibre_rate_limit_service/internal/policies"
+	"fibre_rate_limit_service/internal/decision"
+	"fibre_rate_limit_service/internal/http/middleware"
+	"fibre_rate_limit_service/pkg/fibermw"
 
 	"github.com/gofiber/fiber/v2"
 )
 
 // CheckHandler validates a request against policy and limiter
-func CheckHandler(c *fiber.Ctx, lm *limiters.Manager, pe *policies.Evaluator) error {
-	// Example: use headers to identify client
-	clientID := c.Get("X-Client-ID")
-	if clientID == "" {
-		clientID = "anonymous"
-	}
-
+func CheckHandler(c *fiber.Ctx, d *decision.Decider) error {
 	// Route name could be extracted from path
-	route := c.Path()
+	res := d.DecideContext(c.UserContext(), decision.Request{
+		ID:       middleware.RequestID(c),
+		ClientID: d.ClientID(fibermw.Headers(c), c.IP()),
+		Route:    c.Path(),
+		Method:   c.Method(),
+		Headers:  fibermw.Headers(c),
+	})
 
-	// Step 1: Evaluate policy
-	policyResult := pe.Evaluate(clientID, route, c)
-	if !policyResult.Allowed {
+	switch {
+	case res.Outcome == decision.PolicyDenied:
 		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
 			"allowed": false,
-			"reason":  policyResult.Reason,
+			"reason":  res.Reason,
 		})
-	}
-
-	// Step 2: Apply limiter
-	l, ok := lm.GetLimiter(route)
-	if !ok {
+	case !res.Limited:
 		// If no limiter defined, allow by default
 		return c.JSON(fiber.Map{
 			"allowed": true,
-			"reason":  "no limiter configured for this route",
+			"reason":  res.Reason,
 		})
-	}
-
-	res := l.Check(clientID)
-	if !res.Allowed {
+	case res.Outcome == decision.RateLimited:
 		return c.Status(fiber.StatusTooManyRequests).JSON(fiber.Map{
 			"allowed":   false,
-			"remaining": res.Remaining,
-			"reset_at":  res.ResetAt,
-			"reason":    "rate limit exceeded",
+			"remaining": res.Result.Remaining,
+			"reset_at":  res.Result.ResetAt,
+			"reason":    res.Reason,
 		})
 	}
 
 	// Allowed
 	return c.JSON(fiber.Map{
 		"allowed":   true,
-		"remaining": res.Remaining,
-		"reset_at":  res.ResetAt,
+		"remaining": res.Result.Remaining,
+		"reset_at":  res.Result.ResetAt,
 	})
 }
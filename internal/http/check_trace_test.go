@@ -0,0 +1,92 @@
+package http
+
+import (
+	"context"
+	"io"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"fibre_rate_limit_service/internal/audit"
+	"fibre_rate_limit_service/internal/decision"
+	"fibre_rate_limit_service/internal/limiters"
+	"fibre_rate_limit_service/internal/policies"
+	"fibre_rate_limit_service/internal/storage"
+	"fibre_rate_limit_service/internal/tracing"
+
+	"github.com/gofiber/fiber/v2"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestCheck_Tracing(t *testing.T) {
+	if _, err := tracing.Setup(context.Background(), tracing.Config{}); err != nil {
+		t.Fatal(err)
+	}
+	rec := tracetest.NewSpanRecorder()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(rec)))
+
+	store := storage.NewShardedMap(4, time.Minute, time.Minute)
+	defer store.Close()
+	lm := limiters.NewManager()
+	lm.SetLimiter("/check", limiters.NewTokenBucket(limiters.TokenBucketConfig{
+		Name:        "/check",
+		Capacity:    3,
+		RefillRate:  1,
+		RefillEvery: time.Hour,
+	}, store))
+
+	app := fiber.New()
+	SetupCheckRoutes(app, Services{
+		Limiters: lm,
+		Policies: policies.NewEvaluator(),
+		Store:    store,
+		Audit:    audit.New(io.Discard),
+		Tracing:  true,
+	})
+
+	const traceID = "4bf92f3577b34da6a3ce929d0e0e4736"
+	req := httptest.NewRequest("POST", "/check", nil)
+	req.Header.Set("traceparent", "00-"+traceID+"-00f067aa0ba902b7-01")
+	resp, err := app.Test(req)
+	if err != nil || resp.StatusCode != fiber.StatusOK {
+		t.Fatalf("check: %v %v", resp, err)
+	}
+
+	spans := map[string]sdktrace.ReadOnlySpan{}
+	for _, s := range rec.Ended() {
+		spans[s.Name()] = s
+		if got := s.SpanContext().TraceID().String(); got != traceID {
+			t.Errorf("span %q in trace %s, want the caller's %s", s.Name(), got, traceID)
+		}
+	}
+	server, ok := spans["POST /check"]
+	if !ok {
+		t.Fatalf("no server span, got %v", spans)
+	}
+	attrs := map[attribute.Key]attribute.Value{}
+	for _, kv := range server.Attributes() {
+		attrs[kv.Key] = kv.Value
+	}
+	if got := attrs[decision.DecisionKey].AsString(); got != "allowed" {
+		t.Errorf("decision = %q, want allowed", got)
+	}
+	if got := attrs[decision.LimiterKey].AsString(); got != "/check" {
+		t.Errorf("limiter = %q, want /check", got)
+	}
+	if got := attrs[decision.RemainingKey].AsInt64(); got != 2 {
+		t.Errorf("remaining = %d, want 2", got)
+	}
+
+	for _, name := range []string{"policy.evaluate", "limiter.check"} {
+		child, ok := spans[name]
+		if !ok {
+			t.Fatalf("no %s span, got %v", name, spans)
+		}
+		if child.Parent().SpanID() != server.SpanContext().SpanID() {
+			t.Errorf("%s is not a child of the server span", name)
+		}
+	}
+}
@@ -0,0 +1,44 @@
+package http
+
+import (
+	"fibre_rate_limit_service/internal/decision"
+	"fibre_rate_limit_service/internal/http/middleware"
+	"fibre_rate_limit_service/pkg/fibermw"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// ExtAuthzPrefix is the path prefix Envoy's ext_authz http_service should be
+// configured with; the original request path follows it.
+const ExtAuthzPrefix = "/ext_authz"
+
+// ExtAuthzHandler implements Envoy/Istio's ext_authz HTTP protocol.
+//
+// Envoy replays the original request's method, path (after ExtAuthzPrefix)
+// and headers. The path, without its query string, is the route; the method
+// is only logged, as limits and policies are per route. A 200 lets the
+// request through; 403 and 429 are returned to the downstream client. The
+// X-RateLimit-* headers are set in every case so they can be listed in
+// allowed_upstream_headers / allowed_client_headers.
+func ExtAuthzHandler(c *fiber.Ctx, d *decision.Decider) error {
+	route := "/" + c.Params("*")
+
+	res := d.DecideContext(c.UserContext(), decision.Request{
+		ID:       middleware.RequestID(c),
+		ClientID: d.ClientID(fibermw.Headers(c), c.IP()),
+		Route:    route,
+		Method:   c.Method(),
+		Headers:  fibermw.Headers(c),
+	})
+
+	fibermw.SetRateLimitHeaders(c, res)
+
+	if !res.Allowed() {
+		return c.Status(decisionStatus(res)).JSON(fiber.Map{
+			"allowed": false,
+			"reason":  res.Reason,
+		})
+	}
+
+	return c.SendStatus(fiber.StatusOK)
+}
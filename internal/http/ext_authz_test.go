@@ -0,0 +1,57 @@
+package http
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func TestExtAuthz(t *testing.T) {
+	app, last := newCheckTestApp(t, "/orders", "/")
+
+	cases := []struct {
+		name      string
+		method    string
+		url       string
+		headers   map[string]string
+		status    int
+		route     string
+		remaining string // expected X-RateLimit-Remaining; "" expects none
+	}{
+		{"allowed", "GET", "/ext_authz/orders?page=2", nil, fiber.StatusOK, "/orders", "0"},
+		{"rate limited", "POST", "/ext_authz/orders", nil, fiber.StatusTooManyRequests, "/orders", "0"},
+		{"other client", "DELETE", "/ext_authz/orders", map[string]string{"X-Client-ID": "b"}, fiber.StatusOK, "/orders", "0"},
+		{"policy denied", "GET", "/ext_authz/admin", nil, fiber.StatusForbidden, "/admin", ""},
+		{"policy passed", "GET", "/ext_authz/admin", map[string]string{"X-Secret": "123"}, fiber.StatusOK, "/admin", ""},
+		{"no limiter", "GET", "/ext_authz/a/b", nil, fiber.StatusOK, "/a/b", ""},
+		{"root", "GET", "/ext_authz/", nil, fiber.StatusOK, "/", "0"},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest(tc.method, tc.url, nil)
+			for k, v := range tc.headers {
+				req.Header.Set(k, v)
+			}
+			resp, err := app.Test(req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if resp.StatusCode != tc.status {
+				t.Fatalf("status %d, want %d", resp.StatusCode, tc.status)
+			}
+			if last.Route != tc.route || last.Method != tc.method {
+				t.Fatalf("decided %s %s, want %s %s", last.Method, last.Route, tc.method, tc.route)
+			}
+			if got := resp.Header.Get("X-RateLimit-Remaining"); got != tc.remaining {
+				t.Fatalf("X-RateLimit-Remaining %q, want %q", got, tc.remaining)
+			}
+			if tc.remaining != "" && (resp.Header.Get("X-RateLimit-Limit") != "1" || resp.Header.Get("X-RateLimit-Reset") == "") {
+				t.Fatalf("missing X-RateLimit-Limit/Reset: %v", resp.Header)
+			}
+			if limited := resp.Header.Get("Retry-After") != ""; limited != (tc.status == fiber.StatusTooManyRequests) {
+				t.Fatalf("Retry-After present = %v on %d", limited, tc.status)
+			}
+		})
+	}
+}
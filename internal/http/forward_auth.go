@@ -0,0 +1,72 @@
+package http
+
+import (
+	"strings"
+
+	"fibre_rate_limit_service/internal/decision"
+	"fibre_rate_limit_service/internal/http/middleware"
+	"fibre_rate_limit_service/pkg/fibermw"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// ForwardAuthHandler handles GET /auth for NGINX auth_request and Traefik
+// forwardAuth.
+//
+// The original request is described by X-Original-URI (NGINX) or
+// X-Forwarded-Uri (Traefik); its path, without the query string, is the
+// route. X-Original-Method / X-Forwarded-Method is only logged, as limits and
+// policies are per route. Responses are 200 when allowed, 401 when a required
+// policy header is missing, 403 when it has the wrong value and 429 when rate
+// limited. NGINX treats anything other than 2xx/401/403 as an error, so map
+// 429 with an error_page there if a distinct response is needed.
+func ForwardAuthHandler(c *fiber.Ctx, d *decision.Decider) error {
+	uri := c.Get("X-Original-URI")
+	if uri == "" {
+		uri = c.Get("X-Forwarded-Uri")
+	}
+	if uri == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "missing X-Original-URI or X-Forwarded-Uri header",
+		})
+	}
+
+	method := c.Get("X-Original-Method")
+	if method == "" {
+		method = c.Get("X-Forwarded-Method")
+	}
+
+	res := d.DecideContext(c.UserContext(), decision.Request{
+		ID:       middleware.RequestID(c),
+		ClientID: d.ClientID(fibermw.Headers(c), c.IP()),
+		Route:    originalRoute(uri),
+		Method:   method,
+		Headers:  fibermw.Headers(c),
+	})
+
+	fibermw.SetRateLimitHeaders(c, res)
+
+	if !res.Allowed() {
+		status := decisionStatus(res)
+		if res.Policy.Missing {
+			status = fiber.StatusUnauthorized
+		}
+		return c.Status(status).JSON(fiber.Map{
+			"allowed": false,
+			"reason":  res.Reason,
+		})
+	}
+
+	return c.SendStatus(fiber.StatusOK)
+}
+
+// originalRoute strips the query string and fragment from a forwarded URI.
+func originalRoute(uri string) string {
+	if i := strings.IndexAny(uri, "?#"); i >= 0 {
+		uri = uri[:i]
+	}
+	if !strings.HasPrefix(uri, "/") {
+		uri = "/" + uri
+	}
+	return uri
+}
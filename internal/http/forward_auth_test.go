@@ -0,0 +1,65 @@
+package http
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func TestForwardAuth(t *testing.T) {
+	app, last := newCheckTestApp(t, "/orders")
+
+	cases := []struct {
+		name    string
+		headers map[string]string
+		status  int
+		route   string
+		method  string
+	}{
+		{"nginx allowed", map[string]string{"X-Original-URI": "/orders?page=2", "X-Original-Method": "GET"}, fiber.StatusOK, "/orders", "GET"},
+		{"traefik rate limited", map[string]string{"X-Forwarded-Uri": "/orders#top", "X-Forwarded-Method": "POST"}, fiber.StatusTooManyRequests, "/orders", "POST"},
+		{"missing policy header", map[string]string{"X-Original-URI": "/admin"}, fiber.StatusUnauthorized, "/admin", ""},
+		{"wrong policy header", map[string]string{"X-Original-URI": "/admin", "X-Secret": "456"}, fiber.StatusForbidden, "/admin", ""},
+		{"policy passed", map[string]string{"X-Original-URI": "/admin?x=1", "X-Secret": "123"}, fiber.StatusOK, "/admin", ""},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", "/auth", nil)
+			for k, v := range tc.headers {
+				req.Header.Set(k, v)
+			}
+			resp, err := app.Test(req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if resp.StatusCode != tc.status {
+				t.Fatalf("status %d, want %d", resp.StatusCode, tc.status)
+			}
+			if last.Route != tc.route || last.Method != tc.method {
+				t.Fatalf("decided %q %s, want %q %s", last.Method, last.Route, tc.method, tc.route)
+			}
+		})
+	}
+
+	resp, err := app.Test(httptest.NewRequest("GET", "/auth", nil))
+	if err != nil || resp.StatusCode != fiber.StatusBadRequest {
+		t.Fatalf("without a forwarded URI: %v %v, want 400", resp, err)
+	}
+}
+
+func TestOriginalRoute(t *testing.T) {
+	for uri, want := range map[string]string{
+		"/orders":          "/orders",
+		"/orders?page=2":   "/orders",
+		"/orders/1#items":  "/orders/1",
+		"/orders?a=b#c":    "/orders",
+		"orders":           "/orders",
+		"?page=2":          "/",
+		"/a/b/?redirect=/": "/a/b/",
+	} {
+		if got := originalRoute(uri); got != want {
+			t.Errorf("originalRoute(%q) = %q, want %q", uri, got, want)
+		}
+	}
+}
@@ -0,0 +1,34 @@
+package http
+
+import (
+	"fibre_rate_limit_service/internal/config"
+	"fibre_rate_limit_service/internal/health"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// HealthzHandler is the liveness probe: the process is up and serving.
+func HealthzHandler(c *fiber.Ctx) error {
+	return c.JSON(fiber.Map{"status": "ok"})
+}
+
+// ReadyzHandler is the readiness probe. It answers 503 with the failing
+// checks while the service shouldn't get traffic.
+func ReadyzHandler(c *fiber.Ctx, r *health.Readiness) error {
+	rep := r.Check()
+	status := fiber.StatusOK
+	if !rep.Ready {
+		status = fiber.StatusServiceUnavailable
+	}
+	return c.Status(status).JSON(rep)
+}
+
+// VersionHandler reports the service metadata and build details.
+func VersionHandler(c *fiber.Ctx) error {
+	meta := config.GetMetadata()
+	return c.JSON(fiber.Map{
+		"service": meta.ServiceName,
+		"version": meta.Version,
+		"build":   config.GetBuildInfo(),
+	})
+}
@@ -0,0 +1,122 @@
+package http
+
+import (
+	"encoding/json"
+	"io"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"fibre_rate_limit_service/internal/audit"
+	"fibre_rate_limit_service/internal/config"
+	"fibre_rate_limit_service/internal/decision"
+	"fibre_rate_limit_service/internal/health"
+	"fibre_rate_limit_service/internal/limiters"
+	"fibre_rate_limit_service/internal/policies"
+	"fibre_rate_limit_service/internal/storage"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func TestHealthEndpoints(t *testing.T) {
+	store := storage.NewShardedMap(4, time.Minute, time.Minute)
+	defer store.Close()
+
+	defer func(v string) { config.Version = v }(config.Version)
+	config.Version = "1.2.3-test"
+
+	lm, pe := limiters.NewManager(), policies.NewEvaluator()
+	d := decision.NewDecider(lm, pe)
+	ready := health.NewReadiness()
+	ready.AddStore(store)
+	app := fiber.New()
+	SetupRouter(app, Services{
+		Limiters:  lm,
+		Policies:  pe,
+		Decider:   d,
+		Store:     store,
+		Audit:     audit.New(io.Discard),
+		Readiness: ready,
+	})
+
+	if status, body := doJSON(t, app, "GET", "/healthz", ""); status != fiber.StatusOK {
+		t.Fatalf("healthz: %d %s", status, body)
+	}
+
+	readyz := func(want int, failing string) {
+		t.Helper()
+		status, body := doJSON(t, app, "GET", "/readyz", "")
+		var rep health.Report
+		if err := json.Unmarshal([]byte(body), &rep); err != nil {
+			t.Fatalf("readyz body %s: %v", body, err)
+		}
+		if status != want || rep.Ready != (want == fiber.StatusOK) {
+			t.Fatalf("readyz: %d %s, want %d", status, body, want)
+		}
+		if failing != "" && rep.Checks[failing] == "ok" {
+			t.Fatalf("readyz: expected %s check to fail, got %s", failing, body)
+		}
+	}
+
+	readyz(fiber.StatusServiceUnavailable, "config")
+	ready.ConfigLoaded()
+	readyz(fiber.StatusOK, "")
+
+	done := ready.Reloading()
+	readyz(fiber.StatusServiceUnavailable, "config")
+	done()
+	readyz(fiber.StatusOK, "")
+
+	// An admin config apply is not ready until it has been applied; holding
+	// the decider's update lock keeps it in progress.
+	held, release := make(chan struct{}), make(chan struct{})
+	go d.Update(func() error {
+		close(held)
+		<-release
+		return nil
+	})
+	<-held
+	applied := make(chan int)
+	go func() {
+		req := httptest.NewRequest("POST", "/admin/config/apply", strings.NewReader(`{"limiters": []}`))
+		resp, err := app.Test(req, -1)
+		if err != nil {
+			applied <- 0
+			return
+		}
+		applied <- resp.StatusCode
+	}()
+	for deadline := time.Now().Add(time.Second); ; time.Sleep(time.Millisecond) {
+		if status, _ := doJSON(t, app, "GET", "/readyz", ""); status == fiber.StatusServiceUnavailable {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("readyz stayed ready during a config apply")
+		}
+	}
+	close(release)
+	if status := <-applied; status != fiber.StatusOK {
+		t.Fatalf("apply: %d", status)
+	}
+	readyz(fiber.StatusOK, "")
+
+	ready.Drain()
+	readyz(fiber.StatusServiceUnavailable, "shutdown")
+
+	store.Close()
+	readyz(fiber.StatusServiceUnavailable, "storage")
+
+	status, body := doJSON(t, app, "GET", "/version", "")
+	var v struct {
+		Service string           `json:"service"`
+		Version string           `json:"version"`
+		Build   config.BuildInfo `json:"build"`
+	}
+	if err := json.Unmarshal([]byte(body), &v); err != nil || status != fiber.StatusOK {
+		t.Fatalf("version: %d %s %v", status, body, err)
+	}
+	if v.Service != config.GetMetadata().ServiceName || v.Version != "1.2.3-test" || v.Build.GoVersion == "" {
+		t.Fatalf("version: unexpected %s", body)
+	}
+}
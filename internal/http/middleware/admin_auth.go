@@ -0,0 +1,307 @@
+package middleware
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"fibre_rate_limit_service/internal/config"
+
+	"github.com/gofiber/fiber/v2"
+	"gopkg.in/yaml.v3"
+)
+
+// principalKey is the c.Locals key holding the authenticated Principal.
+const principalKey = "admin.principal"
+
+// Role grants access to admin endpoints. Each role includes the ones below
+// it.
+type Role int
+
+const (
+	// RoleReadOnly may call GET endpoints.
+	RoleReadOnly Role = iota + 1
+	// RoleOperator may also reset and grant keys and manage overrides.
+	RoleOperator
+	// RoleAdmin may also change limiters, policies and the config.
+	RoleAdmin
+)
+
+var roleNames = map[Role]string{
+	RoleReadOnly: "read-only",
+	RoleOperator: "operator",
+	RoleAdmin:    "admin",
+}
+
+func (r Role) String() string {
+	if name, ok := roleNames[r]; ok {
+		return name
+	}
+	return "role(" + strconv.Itoa(int(r)) + ")"
+}
+
+// ParseRole parses "read-only", "operator" or "admin".
+func ParseRole(s string) (Role, error) {
+	for r, name := range roleNames {
+		if name == s {
+			return r, nil
+		}
+	}
+	return 0, fmt.Errorf("%w: unknown role %q (want read-only, operator or admin)", config.ErrInvalidConfig, s)
+}
+
+// UnmarshalYAML decodes a role name.
+func (r *Role) UnmarshalYAML(n *yaml.Node) error {
+	role, err := ParseRole(n.Value)
+	if err != nil {
+		return fmt.Errorf("line %d: %w", n.Line, err)
+	}
+	*r = role
+	return nil
+}
+
+// Principal is an authenticated admin caller.
+type Principal struct {
+	Name string
+	Role Role
+}
+
+// PrincipalFrom returns the caller authenticated by AdminAuth.Authenticate.
+func PrincipalFrom(c *fiber.Ctx) (Principal, bool) {
+	p, ok := c.Locals(principalKey).(Principal)
+	return p, ok
+}
+
+// AdminAuthConfig lists the credentials accepted by the admin API. It is
+// read from YAML:
+//
+//	tokens:
+//	  - {name: alice, role: admin, token: "..."}
+//	hmac_keys:
+//	  - {name: deploy-bot, role: operator, key_id: bot, secret: "..."}
+//	max_skew: 300 # seconds a signed request's timestamp may be off
+type AdminAuthConfig struct {
+	Tokens []struct {
+		Name  string `yaml:"name"`
+		Role  Role   `yaml:"role"`
+		Token string `yaml:"token"`
+	} `yaml:"tokens"`
+	HMACKeys []struct {
+		Name   string `yaml:"name"`
+		Role   Role   `yaml:"role"`
+		KeyID  string `yaml:"key_id"`
+		Secret string `yaml:"secret"`
+	} `yaml:"hmac_keys"`
+	MaxSkew int `yaml:"max_skew"`
+}
+
+// AdminAuth authenticates admin requests with static bearer tokens or HMAC
+// signatures and enforces roles. A nil *AdminAuth allows every request.
+//
+// A bearer token is sent as "Authorization: Bearer <token>". A signed
+// request sends "Authorization: HMAC <key_id>:<unix seconds>:<signature>",
+// where the signature is the hex HMAC-SHA256, keyed with the secret, of
+//
+//	METHOD \n PATH?QUERY \n TIMESTAMP \n hex(sha256(body))
+//
+// Each signature is accepted once while its timestamp is within max_skew, so
+// a captured request can't be replayed; clients sign every request afresh.
+// The record of used signatures is per process.
+type AdminAuth struct {
+	tokens  map[string]Principal
+	keys    map[string]hmacKey
+	maxSkew time.Duration
+
+	mu   sync.Mutex
+	used map[string]time.Time // accepted signature -> when it goes stale
+}
+
+type hmacKey struct {
+	Principal
+	secret []byte
+}
+
+// LoadAdminAuth reads an AdminAuthConfig file.
+func LoadAdminAuth(path string) (*AdminAuth, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var cfg AdminAuthConfig
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	dec.KnownFields(true)
+	if err := dec.Decode(&cfg); err != nil {
+		return nil, fmt.Errorf("%s: %w: %v", path, config.ErrInvalidConfig, err)
+	}
+	a, err := NewAdminAuth(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return a, nil
+}
+
+// NewAdminAuth validates cfg and builds an AdminAuth.
+func NewAdminAuth(cfg AdminAuthConfig) (*AdminAuth, error) {
+	a := &AdminAuth{
+		tokens:  make(map[string]Principal, len(cfg.Tokens)),
+		keys:    make(map[string]hmacKey, len(cfg.HMACKeys)),
+		maxSkew: 5 * time.Minute,
+		used:    make(map[string]time.Time),
+	}
+	if cfg.MaxSkew > 0 {
+		a.maxSkew = time.Duration(cfg.MaxSkew) * time.Second
+	}
+
+	for i, t := range cfg.Tokens {
+		switch {
+		case t.Name == "" || t.Token == "":
+			return nil, fmt.Errorf("%w: tokens[%d]: name and token are required", config.ErrInvalidConfig, i)
+		case t.Role == 0:
+			return nil, fmt.Errorf("%w: tokens[%d]: role is required", config.ErrInvalidConfig, i)
+		}
+		if _, dup := a.tokens[t.Token]; dup {
+			return nil, fmt.Errorf("%w: tokens[%d]: duplicate token", config.ErrInvalidConfig, i)
+		}
+		a.tokens[t.Token] = Principal{Name: t.Name, Role: t.Role}
+	}
+	for i, k := range cfg.HMACKeys {
+		switch {
+		case k.Name == "" || k.KeyID == "" || k.Secret == "":
+			return nil, fmt.Errorf("%w: hmac_keys[%d]: name, key_id and secret are required", config.ErrInvalidConfig, i)
+		case k.Role == 0:
+			return nil, fmt.Errorf("%w: hmac_keys[%d]: role is required", config.ErrInvalidConfig, i)
+		}
+		if _, dup := a.keys[k.KeyID]; dup {
+			return nil, fmt.Errorf("%w: hmac_keys[%d]: duplicate key_id %q", config.ErrInvalidConfig, i, k.KeyID)
+		}
+		a.keys[k.KeyID] = hmacKey{Principal: Principal{Name: k.Name, Role: k.Role}, secret: []byte(k.Secret)}
+	}
+	if len(a.tokens) == 0 && len(a.keys) == 0 {
+		return nil, fmt.Errorf("%w: no tokens or hmac_keys configured", config.ErrInvalidConfig)
+	}
+	return a, nil
+}
+
+// Authenticate identifies the caller and stores the Principal for Require
+// and PrincipalFrom. Requests without valid credentials get 401.
+func (a *AdminAuth) Authenticate() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		if a == nil {
+			return c.Next()
+		}
+
+		scheme, cred, _ := strings.Cut(c.Get(fiber.HeaderAuthorization), " ")
+		var (
+			p  Principal
+			ok bool
+		)
+		switch strings.ToLower(scheme) {
+		case "bearer":
+			p, ok = a.Token(cred)
+		case "hmac":
+			p, ok = a.verify(c, cred, time.Now())
+		}
+		if !ok {
+			c.Set(fiber.HeaderWWWAuthenticate, `Bearer realm="admin"`)
+			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+				"error": "authentication required",
+			})
+		}
+
+		c.Locals(principalKey, p)
+		return c.Next()
+	}
+}
+
+// Require rejects callers whose role is below role with 403.
+func (a *AdminAuth) Require(role Role) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		if a == nil {
+			return c.Next()
+		}
+		p, ok := PrincipalFrom(c)
+		if !ok || p.Role < role {
+			return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+				"error": "requires the " + role.String() + " role",
+			})
+		}
+		return c.Next()
+	}
+}
+
+// Token returns the principal a bearer token belongs to.
+func (a *AdminAuth) Token(token string) (Principal, bool) {
+	// Compare against every token so timing doesn't reveal near misses.
+	var (
+		found Principal
+		ok    bool
+	)
+	for t, p := range a.tokens {
+		if subtle.ConstantTimeCompare([]byte(t), []byte(token)) == 1 {
+			found, ok = p, true
+		}
+	}
+	return found, ok
+}
+
+func (a *AdminAuth) verify(c *fiber.Ctx, cred string, now time.Time) (Principal, bool) {
+	parts := strings.Split(cred, ":")
+	if len(parts) != 3 {
+		return Principal{}, false
+	}
+	key, ok := a.keys[parts[0]]
+	if !ok {
+		return Principal{}, false
+	}
+	ts, err := strconv.ParseInt(parts[1], 10, 64)
+	if err != nil {
+		return Principal{}, false
+	}
+	if skew := now.Sub(time.Unix(ts, 0)); skew > a.maxSkew || skew < -a.maxSkew {
+		return Principal{}, false
+	}
+
+	want := Sign(key.secret, c.Method(), c.OriginalURL(), parts[1], c.Body())
+	if !hmac.Equal([]byte(want), []byte(parts[2])) {
+		return Principal{}, false
+	}
+	if !a.firstUse(want, time.Unix(ts, 0).Add(a.maxSkew), now) {
+		return Principal{}, false
+	}
+	return key.Principal, true
+}
+
+// firstUse records a verified signature until it goes stale, reporting
+// whether it hadn't been seen before. Stale records are dropped as it goes,
+// so only signatures from the last 2*maxSkew are kept.
+func (a *AdminAuth) firstUse(sig string, stale, now time.Time) bool {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	for s, t := range a.used {
+		if now.After(t) {
+			delete(a.used, s)
+		}
+	}
+	if _, seen := a.used[sig]; seen {
+		return false
+	}
+	a.used[sig] = stale
+	return true
+}
+
+// Sign returns the hex HMAC-SHA256 signature of a request, for clients of the
+// HMAC scheme. uri is the path with its query string.
+func Sign(secret []byte, method, uri, timestamp string, body []byte) string {
+	sum := sha256.Sum256(body)
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(method + "\n" + uri + "\n" + timestamp + "\n" + hex.EncodeToString(sum[:])))
+	return hex.EncodeToString(mac.Sum(nil))
+}
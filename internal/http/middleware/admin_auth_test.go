@@ -0,0 +1,84 @@
+package middleware
+
+import (
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"gopkg.in/yaml.v3"
+)
+
+func TestAdminAuth(t *testing.T) {
+	var cfg AdminAuthConfig
+	err := yaml.Unmarshal([]byte(`
+tokens:
+  - {name: viewer, role: read-only, token: view-token}
+  - {name: alice, role: admin, token: admin-token}
+hmac_keys:
+  - {name: bot, role: operator, key_id: bot, secret: s3cret}
+`), &cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	auth, err := NewAdminAuth(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	app := fiber.New()
+	admin := app.Group("/admin", auth.Authenticate())
+	admin.Get("/limiters", auth.Require(RoleReadOnly), func(c *fiber.Ctx) error {
+		p, _ := PrincipalFrom(c)
+		return c.SendString(p.Name)
+	})
+	admin.Delete("/limiters/x/keys/k", auth.Require(RoleOperator), func(c *fiber.Ctx) error {
+		return c.SendStatus(fiber.StatusOK)
+	})
+
+	do := func(method, target, authz string) int {
+		t.Helper()
+		req := httptest.NewRequest(method, target, nil)
+		if authz != "" {
+			req.Header.Set("Authorization", authz)
+		}
+		resp, err := app.Test(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return resp.StatusCode
+	}
+	signed := func(method, target string, at time.Time) string {
+		ts := strconv.FormatInt(at.Unix(), 10)
+		return "HMAC bot:" + ts + ":" + Sign([]byte("s3cret"), method, target, ts, nil)
+	}
+
+	once := signed("DELETE", "/admin/limiters/x/keys/k", time.Now())
+
+	cases := []struct {
+		name, method, target, authz string
+		want                        int
+	}{
+		{"no credentials", "GET", "/admin/limiters", "", fiber.StatusUnauthorized},
+		{"unknown token", "GET", "/admin/limiters", "Bearer nope", fiber.StatusUnauthorized},
+		{"read-only reads", "GET", "/admin/limiters", "Bearer view-token", fiber.StatusOK},
+		{"read-only can't reset keys", "DELETE", "/admin/limiters/x/keys/k", "Bearer view-token", fiber.StatusForbidden},
+		{"admin resets keys", "DELETE", "/admin/limiters/x/keys/k", "Bearer admin-token", fiber.StatusOK},
+		{"signed operator", "DELETE", "/admin/limiters/x/keys/k", once, fiber.StatusOK},
+		{"replayed signature", "DELETE", "/admin/limiters/x/keys/k", once, fiber.StatusUnauthorized},
+		{"signed again", "DELETE", "/admin/limiters/x/keys/k", signed("DELETE", "/admin/limiters/x/keys/k", time.Now().Add(-time.Second)), fiber.StatusOK},
+		{"signature for another path", "DELETE", "/admin/limiters/x/keys/k", signed("DELETE", "/admin/limiters", time.Now()), fiber.StatusUnauthorized},
+		{"stale signature", "DELETE", "/admin/limiters/x/keys/k", signed("DELETE", "/admin/limiters/x/keys/k", time.Now().Add(-time.Hour)), fiber.StatusUnauthorized},
+	}
+	for _, tc := range cases {
+		if got := do(tc.method, tc.target, tc.authz); got != tc.want {
+			t.Errorf("%s: expected %d, got %d", tc.name, tc.want, got)
+		}
+	}
+
+	if _, err := NewAdminAuth(AdminAuthConfig{}); err == nil || !strings.Contains(err.Error(), "no tokens") {
+		t.Fatalf("expected an empty config to be rejected, got %v", err)
+	}
+}
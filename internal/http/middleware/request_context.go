@@ -1,10 +1,63 @@
 package middleware
 
-import "github.com/gofiber/fiber/v2"
+import (
+	"crypto/rand"
+	"encoding/hex"
 
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/utils"
+)
+
+// requestIDKey is the c.Locals key holding the request ID.
+const requestIDKey = "request.id"
+
+// maxRequestIDLen bounds caller-supplied request IDs, which end up in logs.
+const maxRequestIDLen = 128
+
+// RequestContext gives every request an ID: the caller's X-Request-ID when it
+// is a reasonable token, or a new random one. The ID is echoed in the
+// response's X-Request-ID header, so proxies using the auth endpoints can
+// forward it upstream, and is available through RequestID.
 func RequestContext() fiber.Handler {
 	return func(c *fiber.Ctx) error {
-		// add metadata if needed
+		id := c.Get(fiber.HeaderXRequestID)
+		if validRequestID(id) {
+			id = utils.CopyString(id)
+		} else {
+			id = newRequestID()
+		}
+		c.Locals(requestIDKey, id)
+		c.Set(fiber.HeaderXRequestID, id)
 		return c.Next()
 	}
 }
+
+// RequestID returns the ID assigned by RequestContext, or the caller's
+// X-Request-ID header when the middleware isn't installed. The result is
+// safe to keep after the request.
+func RequestID(c *fiber.Ctx) string {
+	if id, ok := c.Locals(requestIDKey).(string); ok {
+		return id
+	}
+	return utils.CopyString(c.Get(fiber.HeaderXRequestID))
+}
+
+// validRequestID accepts printable ASCII without spaces, up to
+// maxRequestIDLen bytes.
+func validRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLen {
+		return false
+	}
+	for i := 0; i < len(id); i++ {
+		if id[i] <= ' ' || id[i] > '~' {
+			return false
+		}
+	}
+	return true
+}
+
+func newRequestID() string {
+	var b [16]byte
+	rand.Read(b[:])
+	return hex.EncodeToString(b[:])
+}
@@ -0,0 +1,45 @@
+package middleware
+
+import (
+	"io"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func TestRequestContext(t *testing.T) {
+	app := fiber.New()
+	app.Use(RequestContext())
+	app.Get("/", func(c *fiber.Ctx) error {
+		return c.SendString(RequestID(c))
+	})
+
+	get := func(id string) (header, body string) {
+		t.Helper()
+		req := httptest.NewRequest("GET", "/", nil)
+		if id != "" {
+			req.Header.Set("X-Request-ID", id)
+		}
+		resp, err := app.Test(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		b, err := io.ReadAll(resp.Body)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return resp.Header.Get("X-Request-ID"), string(b)
+	}
+
+	if header, body := get("abc-123"); header != "abc-123" || body != "abc-123" {
+		t.Errorf("caller's ID: header %q, handler saw %q", header, body)
+	}
+	for _, id := range []string{"", "has space", strings.Repeat("x", maxRequestIDLen+1)} {
+		header, body := get(id)
+		if len(header) != 32 || header != body || header == id {
+			t.Errorf("ID %q: expected a generated ID, got header %q, handler saw %q", id, header, body)
+		}
+	}
+}
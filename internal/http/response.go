@@ -1,6 +1,11 @@
 package http
 
-import "github.com/gofiber/fiber/v2"
+import (
+	"net/url"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/utils"
+)
 
 // JSONResponse creates a consistent JSON response wrapper.
 func JSONResponse(c *fiber.Ctx, status int, data interface{}) error {
@@ -15,3 +20,10 @@ func JSONError(c *fiber.Ctx, status int, msg string) error {
 		"error": msg,
 	})
 }
+
+// pathParam returns a URL-decoded route parameter. Fiber's parameters point
+// into a buffer that is reused after the request, so the result is copied:
+// names and keys end up stored in the limiter manager, policies and audit log.
+func pathParam(c *fiber.Ctx, name string) (string, error) {
+	return url.PathUnescape(utils.CopyString(c.Params(name)))
+}
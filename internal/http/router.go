@@ -1,30 +1,245 @@
 package http
 
 import (
+	"fibre_rate_limit_service/internal/audit"
+	"fibre_rate_limit_service/internal/config/configfile"
+	"fibre_rate_limit_service/internal/decision"
+	"fibre_rate_limit_service/internal/health"
+	"fibre_rate_limit_service/internal/hotkeys"
+	"fibre_rate_limit_service/internal/http/middleware"
 	"fibre_rate_limit_service/internal/limiters"
+	"fibre_rate_limit_service/internal/metrics"
 	"fibre_rate_limit_service/internal/policies"
 	"fibre_rate_limit_service/internal/storage"
+	"fibre_rate_limit_service/internal/stream"
+	"fibre_rate_limit_service/internal/tracing"
 
 	"github.com/gofiber/fiber/v2"
 )
 
-func SetupRouter(app *fiber.App, lm *limiters.Manager, pe *policies.Evaluator, store *storage.ShardedMap) {
+// DefaultHistorySize is how many config versions are kept when Services
+// doesn't provide a History.
+const DefaultHistorySize = 50
+
+// Services are the shared components the routes operate on.
+type Services struct {
+	Limiters *limiters.Manager
+	Policies *policies.Evaluator
+	Store    *storage.ShardedMap
+	Audit    *audit.Log
+	// Decider is built from Limiters and Policies when nil.
+	Decider *decision.Decider
+	// History is started from the current state when nil.
+	History *configfile.History
+	// AdminAuth protects the /admin routes; nil leaves them open.
+	AdminAuth *middleware.AdminAuth
+	// HotKeys tracks the keys behind GET /admin/limiters/:name/top. When nil,
+	// a default tracker is created and registered with the Decider.
+	HotKeys *hotkeys.Tracker
+	// Stream feeds GET /admin/stream. When nil, a hub is created and
+	// registered with the Decider.
+	Stream *stream.Hub
+	// Metrics, if set, is served at /metrics and times the check endpoints.
+	Metrics *metrics.Metrics
+	// Tracing starts a span for each check request, continuing the caller's
+	// trace. Enable it once tracing.Setup has installed an exporter.
+	Tracing bool
+	// Readiness backs GET /readyz. When nil, readiness covers Store only and
+	// the config counts as loaded.
+	Readiness *health.Readiness
+}
+
+// withDefaults fills in the optional services.
+func (s Services) withDefaults() Services {
+	if s.Decider == nil {
+		s.Decider = decision.NewDecider(s.Limiters, s.Policies)
+	}
+	if s.History == nil {
+		s.History = configfile.NewHistory(DefaultHistorySize, s.Limiters, s.Policies, s.Decider)
+	}
+	if s.HotKeys == nil {
+		s.HotKeys = hotkeys.New(hotkeys.DefaultCapacity, hotkeys.DefaultSlot, hotkeys.DefaultSlots)
+		s.Decider.Observe(s.HotKeys.Observe)
+	}
+	if s.Stream == nil {
+		s.Stream = stream.NewHub()
+		s.Decider.Observe(s.Stream.Observe)
+	}
+	if s.Readiness == nil {
+		s.Readiness = defaultReadiness(s.Store)
+	}
+	return s
+}
+
+func defaultReadiness(store *storage.ShardedMap) *health.Readiness {
+	r := health.NewReadiness()
+	if store != nil {
+		r.AddStore(store)
+	}
+	r.ConfigLoaded()
+	return r
+}
+
+// SetupRouter registers the check and admin routes on one app.
+func SetupRouter(app *fiber.App, s Services) {
+	s = s.withDefaults()
+	SetupCheckRoutes(app, s)
+	SetupAdminRoutes(app, s)
+}
+
+// SetupCheckRoutes registers /check, the proxy auth endpoints and the
+// health probes.
+func SetupCheckRoutes(app *fiber.App, s Services) {
+	d := s.Decider
+	if d == nil {
+		d = decision.NewDecider(s.Limiters, s.Policies)
+	}
+	ready := s.Readiness
+	if ready == nil {
+		ready = defaultReadiness(s.Store)
+	}
+
 	api := app.Group("/")
+	// Kubernetes probes and build details
+	api.Get("/healthz", HealthzHandler)
+	api.Get("/readyz", func(c *fiber.Ctx) error {
+		return ReadyzHandler(c, ready)
+	})
+	api.Get("/version", VersionHandler)
+	if s.Metrics != nil {
+		api.Get("/metrics", s.Metrics.Handler())
+	}
+
+	// instrument puts the optional trace span and latency timer in front of
+	// a check handler.
+	instrument := func(endpoint string, h fiber.Handler) []fiber.Handler {
+		var hs []fiber.Handler
+		if s.Tracing {
+			hs = append(hs, tracing.Middleware())
+		}
+		if s.Metrics != nil {
+			hs = append(hs, s.Metrics.Timer(endpoint))
+		}
+		return append(hs, h)
+	}
 
 	// /check endpoint
-	api.Post("/check", func(c *fiber.Ctx) error {
-		return CheckHandler(c, lm, pe)
-	})
+	api.Post("/check", instrument("check", func(c *fiber.Ctx) error {
+		return CheckHandler(c, d)
+	})...)
+
+	// Envoy/Istio ext_authz HTTP service
+	api.All(ExtAuthzPrefix+"/*", instrument("ext_authz", func(c *fiber.Ctx) error {
+		return ExtAuthzHandler(c, d)
+	})...)
+
+	// NGINX auth_request / Traefik forwardAuth
+	api.Get("/auth", instrument("auth", func(c *fiber.Ctx) error {
+		return ForwardAuthHandler(c, d)
+	})...)
+}
+
+// SetupAdminRoutes registers the /admin routes, e.g. on a separate admin
+// listener. Pass the same Decider and History as to SetupCheckRoutes.
+func SetupAdminRoutes(app *fiber.App, s Services) {
+	s = s.withDefaults()
+	lm, pe, store, al, d, history := s.Limiters, s.Policies, s.Store, s.Audit, s.Decider, s.History
 
-	// Admin endpoints
-	admin := api.Group("/admin")
-	admin.Post("/limiters", func(c *fiber.Ctx) error {
-		return AdminLimitersHandler(c, lm, store) // pass store
+	auth := s.AdminAuth
+	read := auth.Require(middleware.RoleReadOnly)
+	operate := auth.Require(middleware.RoleOperator)
+	manage := auth.Require(middleware.RoleAdmin)
+
+	// The dashboard is registered ahead of the group's middleware, so browsers
+	// can load it; its API calls authenticate like any other client.
+	app.Get("/admin/ui", AdminUIHandler)
+	app.Get("/admin/ui/*", AdminUIHandler)
+
+	admin := app.Group("/admin", auth.Authenticate(), commitVersions(history))
+	admin.Post("/limiters", manage, func(c *fiber.Ctx) error {
+		return AdminLimitersHandler(c, lm, d, store, al)
+	})
+	admin.Get("/limiters", read, func(c *fiber.Ctx) error {
+		return AdminListLimitersHandler(c, lm)
+	})
+	// Limiter names are routes, so clients URL-encode them (e.g. %2Fcheck)
+	admin.Get("/limiters/:name", read, func(c *fiber.Ctx) error {
+		return AdminGetLimiterHandler(c, lm)
+	})
+	admin.Put("/limiters/:name", manage, func(c *fiber.Ctx) error {
+		return AdminPutLimiterHandler(c, lm, d, store, al)
+	})
+	admin.Delete("/limiters/:name", manage, func(c *fiber.Ctx) error {
+		return AdminDeleteLimiterHandler(c, lm, d, al)
+	})
+	admin.Get("/limiters/:name/top", read, func(c *fiber.Ctx) error {
+		return AdminTopKeysHandler(c, lm, s.HotKeys)
+	})
+	admin.Get("/limiters/:name/keys/:key", read, func(c *fiber.Ctx) error {
+		return AdminGetKeyHandler(c, lm)
+	})
+	admin.Put("/limiters/:name/keys/:key", operate, func(c *fiber.Ctx) error {
+		return AdminGrantKeyHandler(c, lm, al)
+	})
+	admin.Delete("/limiters/:name/keys/:key", operate, func(c *fiber.Ctx) error {
+		return AdminResetKeyHandler(c, lm, al)
+	})
+	admin.Get("/limiters/:name/overrides", read, func(c *fiber.Ctx) error {
+		return AdminListOverridesHandler(c, lm)
+	})
+	admin.Put("/limiters/:name/overrides/:key", operate, func(c *fiber.Ctx) error {
+		return AdminPutOverrideHandler(c, lm, d, store, al)
+	})
+	admin.Delete("/limiters/:name/overrides/:key", operate, func(c *fiber.Ctx) error {
+		return AdminDeleteOverrideHandler(c, lm, d, al)
+	})
+	admin.Post("/policies", manage, func(c *fiber.Ctx) error {
+		return AdminPoliciesHandler(c, pe, d, al)
+	})
+	admin.Get("/policies", read, func(c *fiber.Ctx) error {
+		return AdminListPoliciesHandler(c, pe)
+	})
+	// Routes are URL-encoded in the path, like limiter names
+	admin.Get("/policies/:route", read, func(c *fiber.Ctx) error {
+		return AdminGetPoliciesHandler(c, pe)
+	})
+	admin.Put("/policies/:route", manage, func(c *fiber.Ctx) error {
+		return AdminReplacePoliciesHandler(c, pe, d, al)
+	})
+	admin.Delete("/policies/:route", manage, func(c *fiber.Ctx) error {
+		return AdminDeletePoliciesHandler(c, pe, d, al)
+	})
+	admin.Get("/policies/:route/rules/:id", read, func(c *fiber.Ctx) error {
+		return AdminGetPolicyRuleHandler(c, pe)
+	})
+	admin.Put("/policies/:route/rules/:id", manage, func(c *fiber.Ctx) error {
+		return AdminPutPolicyRuleHandler(c, pe, d, al)
+	})
+	admin.Delete("/policies/:route/rules/:id", manage, func(c *fiber.Ctx) error {
+		return AdminDeletePolicyRuleHandler(c, pe, d, al)
+	})
+	admin.Get("/config", read, func(c *fiber.Ctx) error {
+		return AdminGetConfigHandler(c, lm, pe, d)
+	})
+	admin.Post("/config/apply", manage, func(c *fiber.Ctx) error {
+		return AdminApplyConfigHandler(c, lm, pe, d, store, al, s.Readiness)
+	})
+	admin.Get("/config/versions", read, func(c *fiber.Ctx) error {
+		return AdminListVersionsHandler(c, history)
+	})
+	admin.Get("/config/versions/:version", read, func(c *fiber.Ctx) error {
+		return AdminGetVersionHandler(c, history)
+	})
+	admin.Post("/config/rollback/:version", manage, func(c *fiber.Ctx) error {
+		return AdminRollbackConfigHandler(c, history, store, al, s.Readiness)
+	})
+	admin.Get("/audit", read, func(c *fiber.Ctx) error {
+		return AdminAuditHandler(c, al)
 	})
-	admin.Post("/policies", func(c *fiber.Ctx) error {
-		return AdminPoliciesHandler(c, pe)
+	admin.Get("/stream", read, func(c *fiber.Ctx) error {
+		return AdminStreamHandler(c, s.Stream)
 	})
-	admin.Get("/snapshot", func(c *fiber.Ctx) error {
+	admin.Get("/snapshot", read, func(c *fiber.Ctx) error {
 		return SnapshotHandler(c, lm)
 	})
 }
@@ -1,9 +1,9 @@
 package http
 
-import "log"
+import "fibre_rate_limit_service/internal/config"
 
 func Start(app interface{ Listen(string) error }) {
 	if err := app.Listen(":8080"); err != nil {
-		log.Println("server error:", err)
+		config.Logger.Error("server error", "err", err)
 	}
 }
@@ -8,17 +8,5 @@ import (
 
 // SnapshotHandler returns the internal memory state of limiters
 func SnapshotHandler(c *fiber.Ctx, lm *limiters.Manager) error {
-	snapshot := make(map[string]interface{})
-
-	for _, name := range lm.ListLimiters() {
-		l, _ := lm.GetLimiter(name)
-
-		// Only supporting TokenBucket for snapshot in memory
-		if tb, ok := l.(*limiters.TokenBucket); ok {
-			tbSnapshot := tb.StoreSnapshot()
-			snapshot[name] = tbSnapshot
-		}
-	}
-
-	return c.JSON(snapshot)
+	return c.JSON(lm.Snapshot())
 }
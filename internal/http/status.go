@@ -0,0 +1,19 @@
+package http
+
+import (
+	"fibre_rate_limit_service/internal/decision"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// decisionStatus maps a decision outcome to its HTTP status code.
+func decisionStatus(d decision.Decision) int {
+	switch d.Outcome {
+	case decision.PolicyDenied:
+		return fiber.StatusForbidden
+	case decision.RateLimited:
+		return fiber.StatusTooManyRequests
+	default:
+		return fiber.StatusOK
+	}
+}
@@ -0,0 +1,306 @@
+// Admin dashboard. Everything goes through the admin API with the token
+// entered on the page, so the server's roles decide what each user may do.
+"use strict";
+
+const RATE_WINDOW = 10; // seconds of decisions behind the live rates
+
+const state = {
+  token: sessionStorage.getItem("ratelimit.token") || "",
+  limiters: [],
+  selected: null,
+  // limiter -> Map(unix second -> {allowed, denied, shadow})
+  rates: new Map(),
+  stream: null,
+};
+
+const $ = (id) => document.getElementById(id);
+const enc = encodeURIComponent;
+
+// el builds an element. Strings become text nodes, so keys and other
+// client-supplied values are never parsed as HTML.
+function el(tag, props, ...children) {
+  const e = document.createElement(tag);
+  Object.assign(e, props);
+  e.append(...children);
+  return e;
+}
+
+function authHeaders() {
+  return state.token ? { Authorization: "Bearer " + state.token } : {};
+}
+
+async function api(method, path, body) {
+  const headers = authHeaders();
+  if (body !== undefined) {
+    headers["Content-Type"] = "application/json";
+  }
+  const resp = await fetch(path, {
+    method,
+    headers,
+    body: body === undefined ? undefined : JSON.stringify(body),
+  });
+  const data = await resp.json().catch(() => ({}));
+  if (!resp.ok) {
+    throw new Error(`${method} ${path}: ${data.error || resp.status + " " + resp.statusText}`);
+  }
+  return data;
+}
+
+function showError(err) {
+  const box = $("error");
+  box.textContent = err ? err.message : "";
+  box.hidden = !err;
+}
+
+// Limiters and policies
+
+async function loadLimiters() {
+  state.limiters = await api("GET", "/admin/limiters");
+  renderLimiters();
+}
+
+function settings(def) {
+  const rest = { ...def };
+  delete rest.name;
+  delete rest.type;
+  delete rest.mode;
+  delete rest.overrides;
+  return Object.entries(rest).map(([k, v]) => `${k}=${v}`).join(" ");
+}
+
+function rate(name, field) {
+  const buckets = state.rates.get(name);
+  if (!buckets) {
+    return "0";
+  }
+  const since = Math.floor(Date.now() / 1000) - RATE_WINDOW;
+  let n = 0;
+  for (const [sec, counts] of buckets) {
+    if (sec > since) {
+      n += counts[field];
+    }
+  }
+  return (n / RATE_WINDOW).toFixed(1);
+}
+
+function renderLimiters() {
+  const rows = state.limiters.map((def) =>
+    el("tr", { className: def.name === state.selected ? "selected" : "" },
+      el("td", {}, el("code", {}, def.name)),
+      el("td", {}, def.type),
+      el("td", {}, def.mode || "enforce"),
+      el("td", {}, el("code", {}, settings(def))),
+      el("td", { className: "num" }, rate(def.name, "allowed")),
+      el("td", { className: "num" }, rate(def.name, "denied")),
+      el("td", { className: "num" }, rate(def.name, "shadow")),
+      el("td", {}, el("button", { onclick: () => select(def.name) }, "Details")),
+    ));
+  $("limiters").replaceChildren(...rows);
+}
+
+async function loadPolicies() {
+  const byRoute = await api("GET", "/admin/policies");
+  const rows = [];
+  for (const route of Object.keys(byRoute).sort()) {
+    for (const rule of byRoute[route]) {
+      rows.push(el("tr", {},
+        el("td", {}, el("code", {}, route)),
+        el("td", {}, rule.id),
+        el("td", {}, rule.header),
+        el("td", {}, el("code", {}, rule.value)),
+        el("td", {}, rule.mode || "enforce"),
+      ));
+    }
+  }
+  $("policies").replaceChildren(...rows);
+}
+
+// Selected limiter: edit form and top keys
+
+function select(name) {
+  state.selected = name;
+  const def = state.limiters.find((d) => d.name === name);
+  const editable = { ...def };
+  delete editable.name;
+  $("detail").hidden = false;
+  $("detail-title").textContent = name;
+  $("definition").value = JSON.stringify(editable, null, 2);
+  renderLimiters();
+  loadTop().catch(showError);
+}
+
+async function save() {
+  const name = state.selected;
+  let def;
+  try {
+    def = JSON.parse($("definition").value);
+  } catch (err) {
+    showError(new Error("definition is not valid JSON: " + err.message));
+    return;
+  }
+  try {
+    await api("PUT", `/admin/limiters/${enc(name)}`, def);
+    showError(null);
+    await loadLimiters();
+    select(name);
+  } catch (err) {
+    showError(err);
+  }
+}
+
+async function resetKey(name, key) {
+  if (!confirm(`Reset ${key} on ${name}?`)) {
+    return;
+  }
+  try {
+    await api("DELETE", `/admin/limiters/${enc(name)}/keys/${enc(key)}`);
+    showError(null);
+    await loadTop();
+  } catch (err) {
+    showError(err);
+  }
+}
+
+function keyRows(name, counts) {
+  if (!counts || counts.length === 0) {
+    return [el("tr", {}, el("td", { colSpan: 3, className: "hint" }, "none"))];
+  }
+  return counts.map((c) => el("tr", {},
+    el("td", {}, el("code", {}, c.key)),
+    el("td", { className: "num" }, String(c.count)),
+    el("td", {}, el("button", { onclick: () => resetKey(name, c.key) }, "Reset")),
+  ));
+}
+
+async function loadTop() {
+  const name = state.selected;
+  if (!name) {
+    return;
+  }
+  const top = await api("GET", `/admin/limiters/${enc(name)}/top?window=5m&n=10`);
+  if (name !== state.selected) {
+    return;
+  }
+  $("denied").replaceChildren(...keyRows(name, top.denied));
+  $("active").replaceChildren(...keyRows(name, top.active));
+}
+
+// Live decisions from GET /admin/stream. EventSource can't send the token,
+// so the stream is read with fetch.
+
+function count(e) {
+  if (!e.limiter) {
+    return;
+  }
+  let buckets = state.rates.get(e.limiter);
+  if (!buckets) {
+    buckets = new Map();
+    state.rates.set(e.limiter, buckets);
+  }
+  const sec = Math.floor(Date.parse(e.time) / 1000);
+  let counts = buckets.get(sec);
+  if (!counts) {
+    counts = { allowed: 0, denied: 0, shadow: 0 };
+    buckets.set(sec, counts);
+  }
+  if (!e.allowed) {
+    counts.denied++;
+  } else if (e.shadow_outcome) {
+    counts.shadow++;
+  } else {
+    counts.allowed++;
+  }
+}
+
+function prune() {
+  const since = Math.floor(Date.now() / 1000) - RATE_WINDOW;
+  for (const buckets of state.rates.values()) {
+    for (const sec of buckets.keys()) {
+      if (sec <= since) {
+        buckets.delete(sec);
+      }
+    }
+  }
+}
+
+function setLive(live) {
+  const s = $("live");
+  s.textContent = live ? "live" : "disconnected";
+  s.className = "status " + (live ? "live" : "down");
+}
+
+async function startStream() {
+  if (state.stream) {
+    state.stream.abort();
+  }
+  const ctrl = new AbortController();
+  state.stream = ctrl;
+  try {
+    const resp = await fetch("/admin/stream", { headers: authHeaders(), signal: ctrl.signal });
+    if (!resp.ok) {
+      throw new Error(`GET /admin/stream: ${resp.status} ${resp.statusText}`);
+    }
+    setLive(true);
+    const reader = resp.body.pipeThrough(new TextDecoderStream()).getReader();
+    let buf = "";
+    for (;;) {
+      const { value, done } = await reader.read();
+      if (done) {
+        break;
+      }
+      buf += value;
+      let end;
+      while ((end = buf.indexOf("\n\n")) >= 0) {
+        const frame = buf.slice(0, end);
+        buf = buf.slice(end + 2);
+        const data = frame.split("\n").find((line) => line.startsWith("data: "));
+        if (data) {
+          count(JSON.parse(data.slice(6)));
+        }
+      }
+    }
+  } catch (err) {
+    if (err.name === "AbortError") {
+      return;
+    }
+    showError(err);
+  }
+  if (state.stream === ctrl) {
+    setLive(false);
+    setTimeout(startStream, 5000);
+  }
+}
+
+// Startup
+
+async function refresh() {
+  try {
+    await Promise.all([loadLimiters(), loadPolicies(), loadTop()]);
+  } catch (err) {
+    showError(err);
+  }
+}
+
+function connect() {
+  showError(null);
+  refresh();
+  startStream();
+}
+
+document.addEventListener("DOMContentLoaded", () => {
+  $("token").value = state.token;
+  $("login").addEventListener("submit", (ev) => {
+    ev.preventDefault();
+    state.token = $("token").value.trim();
+    sessionStorage.setItem("ratelimit.token", state.token);
+    connect();
+  });
+  $("save").addEventListener("click", save);
+
+  connect();
+  setInterval(() => {
+    prune();
+    renderLimiters();
+  }, 1000);
+  setInterval(refresh, 10000);
+});
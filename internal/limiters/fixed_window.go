@@ -1,8 +1,10 @@
 package limiters
 
 import (
+	"fmt"
 	"time"
 
+	"fibre_rate_limit_service/internal/config"
 	"fibre_rate_limit_service/internal/storage"
 )
 
@@ -14,6 +16,56 @@ type FixedWindowConfig struct {
 	TTL    time.Duration // optional TTL for storage
 }
 
+// Validate reports settings that would make the limiter unusable.
+func (cfg FixedWindowConfig) Validate() error {
+	if cfg.Name == "" {
+		return fmt.Errorf("%w: name is required", config.ErrInvalidConfig)
+	}
+	return cfg.validateSettings()
+}
+
+func (cfg FixedWindowConfig) validateSettings() error {
+	switch {
+	case cfg.Limit <= 0:
+		return fmt.Errorf("%w: limit must be positive", config.ErrInvalidConfig)
+	case cfg.Window <= 0:
+		return fmt.Errorf("%w: window must be positive", config.ErrInvalidConfig)
+	case cfg.TTL < 0:
+		return fmt.Errorf("%w: ttl must not be negative", config.ErrInvalidConfig)
+	}
+	return nil
+}
+
+// FixedWindowSpec is the "fixed-window" type's declarative form.
+type FixedWindowSpec struct {
+	Limit  int `json:"limit"`
+	Window int `json:"window"` // seconds
+	TTL    int `json:"ttl"`    // seconds
+}
+
+func init() {
+	Register("fixed-window", func() Spec { return &FixedWindowSpec{} })
+}
+
+func (s *FixedWindowSpec) config(name string) FixedWindowConfig {
+	return FixedWindowConfig{
+		Name:   name,
+		Limit:  s.Limit,
+		Window: time.Duration(s.Window) * time.Second,
+		TTL:    time.Duration(s.TTL) * time.Second,
+	}
+}
+
+// Validate implements Spec.
+func (s *FixedWindowSpec) Validate() error {
+	return s.config("").validateSettings()
+}
+
+// New implements Spec.
+func (s *FixedWindowSpec) New(name string, store *storage.ShardedMap) Limiter {
+	return NewFixedWindowLimiter(s.config(name), store)
+}
+
 // FixedWindowLimiter implements Limiter interface
 type FixedWindowLimiter struct {
 	cfg   FixedWindowConfig
@@ -36,50 +88,101 @@ func (fw *FixedWindowLimiter) Name() string {
 // Check implements rate limiting logic
 func (fw *FixedWindowLimiter) Check(key string) Result {
 	now := time.Now()
+	allowed := false
 
-	// Load current counter
-	raw, _ := fw.store.Get(key)
-	count := 0
-	windowStart := now
+	v := fw.store.Update(stateKey(fw.cfg.Name, key), fw.cfg.TTL, func(old interface{}, _ bool) interface{} {
+		state := fw.current(old, now)
 
-	if raw != nil {
-		entry := raw.(windowState)
-		count = entry.Count
-		windowStart = entry.Start
-	}
+		if state.Count < fw.cfg.Limit {
+			state.Count++
+			allowed = true
+		}
+		return state
+	})
+	state := v.(windowState)
 
-	// Reset window if expired
-	if now.Sub(windowStart) >= fw.cfg.Window {
-		count = 0
-		windowStart = now
+	return Result{
+		Allowed:   allowed,
+		Limit:     fw.cfg.Limit,
+		Remaining: fw.cfg.Limit - state.Count,
+		ResetAt:   state.Start.Add(fw.cfg.Window),
+		Reason:    "",
 	}
+}
 
-	allowed := false
-	if count < fw.cfg.Limit {
-		count++
-		allowed = true
+// current returns the stored counter, or a fresh one when the window expired.
+func (fw *FixedWindowLimiter) current(raw interface{}, now time.Time) windowState {
+	state, ok := raw.(windowState)
+	if !ok || now.Sub(state.Start) >= fw.cfg.Window {
+		return windowState{Start: now}
 	}
+	return state
+}
+
+// KeyState implements KeyManager.
+func (fw *FixedWindowLimiter) KeyState(key string) (KeyState, bool) {
+	raw, found := fw.store.Get(stateKey(fw.cfg.Name, key))
+	return fw.keyState(key, fw.current(raw, time.Now())), found
+}
 
-	// Save updated state
-	fw.store.Set(key, windowState{
-		Start: windowStart,
-		Count: count,
-	}, fw.cfg.TTL)
+// ResetKey implements KeyManager, starting a fresh window for the key.
+func (fw *FixedWindowLimiter) ResetKey(key string) {
+	fw.store.Delete(stateKey(fw.cfg.Name, key))
+}
 
-	return Result{
-		Allowed:   allowed,
-		Remaining: fw.cfg.Limit - count,
-		ResetAt:   windowStart.Add(fw.cfg.Window),
-		Reason:    "",
+// GrantKey implements KeyManager by allowing n extra requests in the
+// current window. The count may drop below zero, leaving Remaining above
+// Limit until the grant is used.
+func (fw *FixedWindowLimiter) GrantKey(key string, n int) KeyState {
+	v := fw.store.Update(stateKey(fw.cfg.Name, key), fw.cfg.TTL, func(old interface{}, _ bool) interface{} {
+		state := fw.current(old, time.Now())
+		state.Count -= n
+		return state
+	})
+	return fw.keyState(key, v.(windowState))
+}
+
+func (fw *FixedWindowLimiter) keyState(key string, state windowState) KeyState {
+	return KeyState{
+		Key:       key,
+		Limit:     fw.cfg.Limit,
+		Remaining: fw.cfg.Limit - state.Count,
+		ResetAt:   state.Start.Add(fw.cfg.Window),
 	}
 }
 
 // UpdateConfig allows updating limiter settings
 func (fw *FixedWindowLimiter) UpdateConfig(cfg Config) {
+	if cfg.Limit <= 0 || cfg.Window <= 0 {
+		return
+	}
 	fw.cfg.Limit = cfg.Limit
 	fw.cfg.Window = cfg.Window
 }
 
+// GetConfig returns the limiter's current configuration
+func (fw *FixedWindowLimiter) GetConfig() FixedWindowConfig {
+	return fw.cfg
+}
+
+// Definition implements Describer.
+func (fw *FixedWindowLimiter) Definition() Definition {
+	return Definition{
+		Name: fw.cfg.Name,
+		Type: "fixed-window",
+		Spec: &FixedWindowSpec{
+			Limit:  fw.cfg.Limit,
+			Window: int(fw.cfg.Window / time.Second),
+			TTL:    int(fw.cfg.TTL / time.Second),
+		},
+	}
+}
+
+// StoreSnapshot returns this limiter's stored entries keyed by client key.
+func (fw *FixedWindowLimiter) StoreSnapshot() map[string]interface{} {
+	return storeSnapshot(fw.store, fw.cfg.Name)
+}
+
 // windowState stores per-key counter and window start time
 type windowState struct {
 	Start time.Time
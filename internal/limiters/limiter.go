@@ -5,6 +5,7 @@ import "time"
 // Result stores the outcome of a limiter check.
 type Result struct {
 	Allowed   bool
+	Limit     int
 	Remaining int
 	ResetAt   time.Time
 	Reason    string
@@ -25,3 +26,29 @@ type Limiter interface {
 	Check(key string) Result
 	UpdateConfig(cfg Config)
 }
+
+// Snapshotter is implemented by limiters that can expose their stored state.
+type Snapshotter interface {
+	StoreSnapshot() map[string]interface{}
+}
+
+// KeyState describes one client key's current standing with a limiter.
+type KeyState struct {
+	Key       string    `json:"key"`
+	Limit     int       `json:"limit"`
+	Remaining int       `json:"remaining"`
+	ResetAt   time.Time `json:"reset_at"`
+}
+
+// KeyManager is implemented by limiters that support per-key admin
+// operations.
+type KeyManager interface {
+	// KeyState reports the key's state and whether any is stored for it.
+	KeyState(key string) (KeyState, bool)
+	// ResetKey forgets the key's state, as if it had never been seen.
+	ResetKey(key string)
+	// GrantKey gives the key n extra requests and returns its new state.
+	// Grants come on top of the limit, so Remaining may exceed Limit until
+	// they are used up or the window ends.
+	GrantKey(key string, n int) KeyState
+}
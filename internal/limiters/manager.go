@@ -1,32 +1,66 @@
 package limiters
 
-import "sync"
+import (
+	"sync"
+
+	"fibre_rate_limit_service/internal/config"
+)
 
 // Manager holds all active limiters.
 type Manager struct {
-	mu       sync.RWMutex
-	limiters map[string]Limiter
+	mu        sync.RWMutex
+	limiters  map[string]Limiter
+	overrides map[string][]override // limiter name -> per-key overrides
+	shadow    map[string]bool       // limiters in shadow mode
 }
 
 // NewManager initializes an empty manager.
 func NewManager() *Manager {
 	return &Manager{
-		limiters: make(map[string]Limiter),
+		limiters:  make(map[string]Limiter),
+		overrides: make(map[string][]override),
+		shadow:    make(map[string]bool),
 	}
 }
 
-// AddLimiter adds or replaces a limiter by name.
+// AddLimiter adds or replaces a limiter by name, in enforce mode.
 func (m *Manager) AddLimiter(l Limiter) {
 	m.mu.Lock()
 	defer m.mu.Unlock()
 	m.limiters[l.Name()] = l
+	delete(m.shadow, l.Name())
 }
 
-// SetLimiter creates or updates a limiter by name
+// SetLimiter creates or updates a limiter by name, in enforce mode.
 func (m *Manager) SetLimiter(name string, l Limiter) {
 	m.mu.Lock()
 	defer m.mu.Unlock()
 	m.limiters[name] = l
+	delete(m.shadow, name)
+}
+
+// Mode returns a limiter's mode.
+func (m *Manager) Mode(name string) config.Mode {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	if m.shadow[name] {
+		return config.ModeShadow
+	}
+	return config.ModeEnforce
+}
+
+// RemoveLimiter deletes a limiter and its overrides by name, reporting
+// whether it existed.
+func (m *Manager) RemoveLimiter(name string) bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if _, ok := m.limiters[name]; !ok {
+		return false
+	}
+	delete(m.limiters, name)
+	delete(m.overrides, name)
+	delete(m.shadow, name)
+	return true
 }
 
 // GetLimiter returns a limiter by name.
@@ -70,3 +104,15 @@ func (m *Manager) Limiters() map[string]Limiter {
 	}
 	return out
 }
+
+// Snapshot returns the in-memory state of every limiter that exposes it.
+func (m *Manager) Snapshot() map[string]interface{} {
+	snapshot := make(map[string]interface{})
+
+	for name, l := range m.Limiters() {
+		if s, ok := l.(Snapshotter); ok {
+			snapshot[name] = s.StoreSnapshot()
+		}
+	}
+	return snapshot
+}
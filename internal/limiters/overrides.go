@@ -0,0 +1,302 @@
+package limiters
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"fibre_rate_limit_service/internal/config"
+	"fibre_rate_limit_service/internal/storage"
+)
+
+// Override replaces a limiter's settings for one client key, or for every key
+// sharing a tenant prefix when Key ends in "*" (e.g. "acme:*"). Spec has the
+// same type as the limiter it belongs to. It marshals to the flat JSON form
+// {"key": ..., <spec fields>}.
+type Override struct {
+	Key  string
+	Spec Spec
+}
+
+// DecodeOverride decodes an override for a limiter of type typ.
+func DecodeOverride(typ string, raw []byte) (Override, error) {
+	var head struct {
+		Key string `json:"key"`
+	}
+	if err := json.Unmarshal(raw, &head); err != nil {
+		return Override{}, err
+	}
+
+	spec, err := DecodeSpec(typ, raw)
+	if err != nil {
+		return Override{}, err
+	}
+	return Override{Key: head.Key, Spec: spec}, nil
+}
+
+// MarshalJSON flattens the spec fields next to the key.
+func (o Override) MarshalJSON() ([]byte, error) {
+	out := map[string]interface{}{}
+	if o.Spec != nil {
+		raw, err := json.Marshal(o.Spec)
+		if err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal(raw, &out); err != nil {
+			return nil, err
+		}
+	}
+	out["key"] = o.Key
+	return json.Marshal(out)
+}
+
+// Validate checks the key and the spec.
+func (o Override) Validate() error {
+	if o.Key == "" || o.Key == "*" {
+		return fmt.Errorf("%w: override key is required", config.ErrInvalidConfig)
+	}
+	if o.Spec == nil {
+		return fmt.Errorf("%w: override %q has no settings", config.ErrInvalidConfig, o.Key)
+	}
+	if err := o.Spec.Validate(); err != nil {
+		return fmt.Errorf("override %q: %w", o.Key, err)
+	}
+	return nil
+}
+
+// matches reports whether the override applies to a client key.
+func (o Override) matches(key string) bool {
+	if prefix, ok := strings.CutSuffix(o.Key, "*"); ok {
+		return strings.HasPrefix(key, prefix)
+	}
+	return o.Key == key
+}
+
+// override is an installed Override with its limiter. The limiter carries the
+// base limiter's name, so it shares the same per-key state.
+type override struct {
+	Override
+	limiter Limiter
+}
+
+func buildOverride(name string, o Override, store *storage.ShardedMap) (override, error) {
+	if err := o.Validate(); err != nil {
+		return override{}, err
+	}
+	return override{Override: o, limiter: o.Spec.New(name, store)}, nil
+}
+
+// SetOverride installs an override on a limiter, replacing any override for
+// the same key.
+func (m *Manager) SetOverride(name string, o Override, store *storage.ShardedMap) error {
+	built, err := buildOverride(name, o, store)
+	if err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.limiters[name]; !ok {
+		return fmt.Errorf("%w: limiter %q not found", config.ErrInvalidConfig, name)
+	}
+
+	list := m.overrides[name]
+	for i := range list {
+		if list[i].Key == o.Key {
+			next := append([]override(nil), list...)
+			next[i] = built
+			m.overrides[name] = next
+			return nil
+		}
+	}
+	m.overrides[name] = append(append([]override(nil), list...), built)
+	return nil
+}
+
+// RemoveOverride deletes a limiter's override for key, reporting whether it
+// existed.
+func (m *Manager) RemoveOverride(name, key string) bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	list := m.overrides[name]
+	for i := range list {
+		if list[i].Key == key {
+			next := append(append([]override(nil), list[:i]...), list[i+1:]...)
+			if len(next) == 0 {
+				delete(m.overrides, name)
+			} else {
+				m.overrides[name] = next
+			}
+			return true
+		}
+	}
+	return false
+}
+
+// Overrides returns a limiter's overrides in the order they were added.
+func (m *Manager) Overrides(name string) []Override {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.overrideList(name)
+}
+
+// overrideList copies a limiter's overrides. Callers must hold m.mu.
+func (m *Manager) overrideList(name string) []Override {
+	list := m.overrides[name]
+	if len(list) == 0 {
+		return nil
+	}
+	out := make([]Override, len(list))
+	for i, o := range list {
+		out[i] = o.Override
+	}
+	return out
+}
+
+// Resolve returns the limiter that applies to a client key, and the named
+// limiter's mode, read together: an exact-key override first, then the
+// longest matching prefix override, then the limiter itself.
+func (m *Manager) Resolve(name, key string) (Limiter, config.Mode, bool) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	l, ok := m.limiters[name]
+	if !ok {
+		return nil, "", false
+	}
+	mode := config.ModeEnforce
+	if m.shadow[name] {
+		mode = config.ModeShadow
+	}
+
+	best := -1
+	for i, o := range m.overrides[name] {
+		if !o.matches(key) {
+			continue
+		}
+		if o.Key == key {
+			return o.limiter, mode, true
+		}
+		if best < 0 || len(o.Key) > len(m.overrides[name][best].Key) {
+			best = i
+		}
+	}
+	if best >= 0 {
+		return m.overrides[name][best].limiter, mode, true
+	}
+	return l, mode, true
+}
+
+// Apply builds a definition's limiter and installs it under its name. When
+// def.Overrides is non-nil the limiter's overrides are replaced as well;
+// otherwise existing overrides are kept. Nothing changes if any part fails
+// validation.
+func (m *Manager) Apply(def Definition, store *storage.ShardedMap) error {
+	b, err := buildDefinition(def, store)
+	if err != nil {
+		return err
+	}
+	m.install([]built{b}, nil)
+	return nil
+}
+
+// ApplyAll installs several definitions, as Apply does, and removes the named
+// limiters in one step: readers see either the old set or the new one.
+// Nothing changes if any definition fails validation. Per-key state lives in
+// the store under the limiter's name, so replacing a limiter keeps it.
+func (m *Manager) ApplyAll(defs []Definition, remove []string, store *storage.ShardedMap) error {
+	all := make([]built, 0, len(defs))
+	for _, def := range defs {
+		b, err := buildDefinition(def, store)
+		if err != nil {
+			return fmt.Errorf("limiter %q: %w", def.Name, err)
+		}
+		all = append(all, b)
+	}
+	m.install(all, remove)
+	return nil
+}
+
+// built is a definition whose limiter and overrides have been constructed.
+type built struct {
+	def       Definition
+	limiter   Limiter
+	overrides []override
+}
+
+func (m *Manager) install(all []built, remove []string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, name := range remove {
+		delete(m.limiters, name)
+		delete(m.overrides, name)
+		delete(m.shadow, name)
+	}
+	for _, b := range all {
+		m.limiters[b.def.Name] = b.limiter
+		if b.def.Mode.Shadow() {
+			m.shadow[b.def.Name] = true
+		} else {
+			delete(m.shadow, b.def.Name)
+		}
+		if b.def.Overrides == nil {
+			continue
+		}
+		if len(b.overrides) == 0 {
+			delete(m.overrides, b.def.Name)
+		} else {
+			m.overrides[b.def.Name] = b.overrides
+		}
+	}
+}
+
+// buildDefinition builds a definition's limiter and overrides without
+// installing them.
+func buildDefinition(def Definition, store *storage.ShardedMap) (built, error) {
+	l, err := def.Build(store)
+	if err != nil {
+		return built{}, err
+	}
+
+	b := built{def: def, limiter: l}
+	if def.Overrides != nil {
+		b.overrides = make([]override, 0, len(def.Overrides))
+		seen := make(map[string]bool, len(def.Overrides))
+		for _, o := range def.Overrides {
+			if seen[o.Key] {
+				return built{}, fmt.Errorf("%w: duplicate override %q", config.ErrInvalidConfig, o.Key)
+			}
+			seen[o.Key] = true
+
+			ob, err := buildOverride(def.Name, o, store)
+			if err != nil {
+				return built{}, err
+			}
+			b.overrides = append(b.overrides, ob)
+		}
+	}
+	return b, nil
+}
+
+// Definition returns a limiter's definition including its mode and
+// overrides, read together.
+func (m *Manager) Definition(name string) (Definition, bool) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	l, ok := m.limiters[name]
+	if !ok {
+		return Definition{}, false
+	}
+
+	def := DefinitionOf(l)
+	def.Name = name
+	if m.shadow[name] {
+		def.Mode = config.ModeShadow
+	}
+	def.Overrides = m.overrideList(name)
+	return def, true
+}
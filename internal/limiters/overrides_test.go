@@ -0,0 +1,82 @@
+package limiters
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"fibre_rate_limit_service/internal/config"
+	"fibre_rate_limit_service/internal/storage"
+)
+
+func TestManager_OverridesResolveByKeyThenPrefix(t *testing.T) {
+	store := storage.NewShardedMap(4, time.Minute, time.Minute)
+	defer store.Close()
+
+	var def Definition
+	err := json.Unmarshal([]byte(`{
+		"name": "/orders", "type": "token-bucket",
+		"capacity": 1, "refill_rate": 1, "refill_every": 3600,
+		"overrides": [
+			{"key": "acme:*", "capacity": 3, "refill_rate": 1, "refill_every": 3600},
+			{"key": "acme:vip", "capacity": 5, "refill_rate": 1, "refill_every": 3600}
+		]
+	}`), &def)
+	if err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+
+	lm := NewManager()
+	if err := lm.Apply(def, store); err != nil {
+		t.Fatalf("Apply: %v", err)
+	}
+
+	for key, want := range map[string]int{"other": 1, "acme:1": 3, "acme:vip": 5} {
+		l, _, _ := lm.Resolve("/orders", key)
+		if got := l.Check(key).Limit; got != want {
+			t.Fatalf("%s: expected limit %d, got %d", key, want, got)
+		}
+	}
+
+	// An override reports its limiter's mode
+	shadowed := def
+	shadowed.Name, shadowed.Mode = "/shadowed", config.ModeShadow
+	if err := lm.Apply(shadowed, store); err != nil {
+		t.Fatalf("Apply shadow: %v", err)
+	}
+	if _, mode, ok := lm.Resolve("/shadowed", "acme:1"); !ok || mode != config.ModeShadow {
+		t.Fatalf("shadow limiter override resolved in mode %q", mode)
+	}
+	if _, mode, _ := lm.Resolve("/orders", "acme:1"); mode != config.ModeEnforce {
+		t.Fatalf("enforced limiter override resolved in mode %q", mode)
+	}
+
+	// Overrides share the base limiter's per-key state.
+	base, _ := lm.GetLimiter("/orders")
+	if r := base.Check("acme:1"); r.Remaining != 1 {
+		t.Fatalf("expected base limiter to see the override's consumed token, got %+v", r)
+	}
+
+	exported, _ := lm.Definition("/orders")
+	raw, _ := json.Marshal(exported)
+	if !strings.Contains(string(raw), `"key":"acme:vip"`) {
+		t.Fatalf("expected overrides in exported definition, got %s", raw)
+	}
+
+	if !lm.RemoveOverride("/orders", "acme:vip") {
+		t.Fatal("expected override to be removed")
+	}
+	if l, _, _ := lm.Resolve("/orders", "acme:vip"); l.Check("acme:vip").Limit != 3 {
+		t.Fatal("expected prefix override after removing exact override")
+	}
+
+	bad := def
+	bad.Overrides = []Override{{Key: "x", Spec: &TokenBucketSpec{Capacity: -1, RefillEvery: 1}}}
+	if err := lm.Apply(bad, store); err == nil {
+		t.Fatal("expected invalid override to be rejected")
+	}
+	if len(lm.Overrides("/orders")) != 1 {
+		t.Fatal("expected failed Apply to leave overrides unchanged")
+	}
+}
@@ -0,0 +1,176 @@
+package limiters
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"fibre_rate_limit_service/internal/config"
+	"fibre_rate_limit_service/internal/storage"
+)
+
+// Spec is the declarative configuration of one limiter type. Specs are
+// decoded from the flat JSON used by the admin API and config files, so their
+// fields carry json tags next to "name" and "type".
+type Spec interface {
+	// Validate reports settings that would make the limiter unusable.
+	Validate() error
+	// New builds a limiter from a validated spec.
+	New(name string, store *storage.ShardedMap) Limiter
+}
+
+var (
+	registryMu sync.RWMutex
+	registry   = make(map[string]func() Spec)
+)
+
+// Register makes a limiter type available by name. newSpec must return a
+// pointer to a zero spec for decoding into.
+func Register(typ string, newSpec func() Spec) {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+	if _, dup := registry[typ]; dup {
+		panic("limiters: type " + typ + " registered twice")
+	}
+	registry[typ] = newSpec
+}
+
+// Types returns the registered type names, sorted.
+func Types() []string {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+	names := make([]string, 0, len(registry))
+	for name := range registry {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// DecodeSpec decodes raw JSON into the spec registered for typ.
+func DecodeSpec(typ string, raw []byte) (Spec, error) {
+	registryMu.RLock()
+	newSpec, ok := registry[typ]
+	registryMu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("%w: unsupported limiter type %q", config.ErrInvalidConfig, typ)
+	}
+
+	spec := newSpec()
+	if err := json.Unmarshal(raw, spec); err != nil {
+		return nil, fmt.Errorf("%w: %v", config.ErrInvalidConfig, err)
+	}
+	return spec, nil
+}
+
+// Definition is a named, typed limiter spec with optional per-key
+// overrides. It marshals to and from the flat JSON form
+// {"name": ..., "type": ..., "mode": ..., <spec fields>, "overrides": [...]}.
+// A limiter in shadow mode is checked as usual but never rejects requests.
+type Definition struct {
+	Name      string
+	Type      string
+	Mode      config.Mode
+	Spec      Spec
+	Overrides []Override
+}
+
+// UnmarshalJSON decodes name and type, then the type's spec fields and
+// overrides.
+func (d *Definition) UnmarshalJSON(data []byte) error {
+	var head struct {
+		Name      string            `json:"name"`
+		Type      string            `json:"type"`
+		Mode      config.Mode       `json:"mode"`
+		Overrides []json.RawMessage `json:"overrides"`
+	}
+	if err := json.Unmarshal(data, &head); err != nil {
+		return err
+	}
+
+	spec, err := DecodeSpec(head.Type, data)
+	if err != nil {
+		return err
+	}
+
+	var overrides []Override
+	if head.Overrides != nil {
+		overrides = make([]Override, 0, len(head.Overrides))
+		for _, raw := range head.Overrides {
+			o, err := DecodeOverride(head.Type, raw)
+			if err != nil {
+				return err
+			}
+			overrides = append(overrides, o)
+		}
+	}
+
+	*d = Definition{Name: head.Name, Type: head.Type, Mode: head.Mode, Spec: spec, Overrides: overrides}
+	return nil
+}
+
+// MarshalJSON flattens the spec fields next to name and type. Mode is only
+// written for shadow limiters, so "enforce" and "" compare equal.
+func (d Definition) MarshalJSON() ([]byte, error) {
+	out := map[string]interface{}{}
+	if d.Spec != nil {
+		raw, err := json.Marshal(d.Spec)
+		if err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal(raw, &out); err != nil {
+			return nil, err
+		}
+	}
+	out["name"] = d.Name
+	out["type"] = d.Type
+	if d.Mode.Shadow() {
+		out["mode"] = config.ModeShadow
+	}
+	if len(d.Overrides) > 0 {
+		out["overrides"] = d.Overrides
+	}
+	return json.Marshal(out)
+}
+
+// Validate checks the name, mode and spec.
+func (d Definition) Validate() error {
+	if d.Name == "" {
+		return fmt.Errorf("%w: name is required", config.ErrInvalidConfig)
+	}
+	if strings.Contains(d.Name, "|") {
+		return fmt.Errorf("%w: name %q must not contain \"|\"", config.ErrInvalidConfig, d.Name)
+	}
+	if err := d.Mode.Validate(); err != nil {
+		return err
+	}
+	if d.Spec == nil {
+		return fmt.Errorf("%w: unsupported limiter type %q", config.ErrInvalidConfig, d.Type)
+	}
+	return d.Spec.Validate()
+}
+
+// Build validates the definition and constructs its limiter. Overrides are
+// installed separately by Manager.Apply.
+func (d Definition) Build(store *storage.ShardedMap) (Limiter, error) {
+	if err := d.Validate(); err != nil {
+		return nil, err
+	}
+	return d.Spec.New(d.Name, store), nil
+}
+
+// Describer is implemented by limiters that can report their definition.
+type Describer interface {
+	Definition() Definition
+}
+
+// DefinitionOf returns a limiter's definition, or just its name and an
+// "unknown" type when it can't describe itself.
+func DefinitionOf(l Limiter) Definition {
+	if d, ok := l.(Describer); ok {
+		return d.Definition()
+	}
+	return Definition{Name: l.Name(), Type: "unknown"}
+}
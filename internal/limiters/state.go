@@ -0,0 +1,26 @@
+package limiters
+
+import (
+	"strings"
+
+	"fibre_rate_limit_service/internal/storage"
+)
+
+// stateKey namespaces a client key by limiter so limiters sharing a store
+// never see each other's state. Definition.Validate rejects "|" in limiter
+// names, so the limiter part always ends at the first "|".
+func stateKey(limiter, key string) string {
+	return limiter + "|" + key
+}
+
+// storeSnapshot returns the entries stored for one limiter, keyed by client key.
+func storeSnapshot(store *storage.ShardedMap, limiter string) map[string]interface{} {
+	prefix := stateKey(limiter, "")
+	result := make(map[string]interface{})
+	for k, v := range store.Snapshot() {
+		if strings.HasPrefix(k, prefix) {
+			result[strings.TrimPrefix(k, prefix)] = v
+		}
+	}
+	return result
+}
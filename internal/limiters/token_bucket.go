@@ -1,8 +1,10 @@
 package limiters
 
 import (
+	"fmt"
 	"time"
 
+	"fibre_rate_limit_service/internal/config"
 	"fibre_rate_limit_service/internal/storage"
 )
 
@@ -15,6 +17,60 @@ type TokenBucketConfig struct {
 	TTL         time.Duration // bucket TTL in storage
 }
 
+// Validate reports settings that would make the bucket unusable.
+func (cfg TokenBucketConfig) Validate() error {
+	if cfg.Name == "" {
+		return fmt.Errorf("%w: name is required", config.ErrInvalidConfig)
+	}
+	return cfg.validateSettings()
+}
+
+func (cfg TokenBucketConfig) validateSettings() error {
+	switch {
+	case cfg.Capacity <= 0:
+		return fmt.Errorf("%w: capacity must be positive", config.ErrInvalidConfig)
+	case cfg.RefillRate < 0:
+		return fmt.Errorf("%w: refill_rate must not be negative", config.ErrInvalidConfig)
+	case cfg.RefillEvery <= 0:
+		return fmt.Errorf("%w: refill_every must be positive", config.ErrInvalidConfig)
+	case cfg.TTL < 0:
+		return fmt.Errorf("%w: ttl must not be negative", config.ErrInvalidConfig)
+	}
+	return nil
+}
+
+// TokenBucketSpec is the "token-bucket" type's declarative form.
+type TokenBucketSpec struct {
+	Capacity    int `json:"capacity"`
+	RefillRate  int `json:"refill_rate"`
+	RefillEvery int `json:"refill_every"` // seconds
+	TTL         int `json:"ttl"`          // seconds
+}
+
+func init() {
+	Register("token-bucket", func() Spec { return &TokenBucketSpec{} })
+}
+
+func (s *TokenBucketSpec) config(name string) TokenBucketConfig {
+	return TokenBucketConfig{
+		Name:        name,
+		Capacity:    s.Capacity,
+		RefillRate:  s.RefillRate,
+		RefillEvery: time.Duration(s.RefillEvery) * time.Second,
+		TTL:         time.Duration(s.TTL) * time.Second,
+	}
+}
+
+// Validate implements Spec.
+func (s *TokenBucketSpec) Validate() error {
+	return s.config("").validateSettings()
+}
+
+// New implements Spec.
+func (s *TokenBucketSpec) New(name string, store *storage.ShardedMap) Limiter {
+	return NewTokenBucket(s.config(name), store)
+}
+
 // TokenBucket implements the Limiter interface.
 type TokenBucket struct { // ✅ uppercase
 	cfg   TokenBucketConfig
@@ -42,19 +98,40 @@ func (tb *TokenBucket) Name() string {
 // Check consumes 1 token if available and returns a Result.
 func (tb *TokenBucket) Check(key string) Result {
 	now := time.Now()
+	allowed := false
+
+	v := tb.store.Update(stateKey(tb.cfg.Name, key), tb.cfg.TTL, func(old interface{}, _ bool) interface{} {
+		state := tb.refill(old, now)
+
+		// Check if allowed
+		if state.Tokens > 0 {
+			state.Tokens--
+			allowed = true
+		}
+		return state
+	})
+	state := v.(bucketState)
+
+	return Result{
+		Allowed:   allowed,
+		Limit:     tb.cfg.Capacity,
+		Remaining: state.Tokens,
+		ResetAt:   state.LastRefill.Add(tb.cfg.RefillEvery),
+		Reason:    "",
+	}
+}
 
-	raw, _ := tb.store.Get(key)
-	var state bucketState
-	if raw == nil {
-		state = bucketState{
+// refill returns the stored state (or a full bucket) topped up for the time
+// elapsed since the last refill.
+func (tb *TokenBucket) refill(raw interface{}, now time.Time) bucketState {
+	state, ok := raw.(bucketState)
+	if !ok {
+		return bucketState{
 			Tokens:     tb.cfg.Capacity,
 			LastRefill: now,
 		}
-	} else {
-		state = raw.(bucketState)
 	}
 
-	// Refill tokens
 	elapsed := now.Sub(state.LastRefill)
 	if elapsed >= tb.cfg.RefillEvery {
 		refills := int(elapsed / tb.cfg.RefillEvery)
@@ -64,26 +141,14 @@ func (tb *TokenBucket) Check(key string) Result {
 		}
 		state.LastRefill = now
 	}
-
-	// Check if allowed
-	allowed := false
-	if state.Tokens > 0 {
-		state.Tokens--
-		allowed = true
-	}
-
-	tb.store.Set(key, state, tb.cfg.TTL)
-
-	return Result{
-		Allowed:   allowed,
-		Remaining: state.Tokens,
-		ResetAt:   state.LastRefill.Add(tb.cfg.RefillEvery),
-		Reason:    "",
-	}
+	return state
 }
 
 // UpdateConfig updates the limiter's config.
 func (tb *TokenBucket) UpdateConfig(cfg Config) {
+	if cfg.Limit <= 0 || cfg.Window < time.Second {
+		return // would divide by zero or leave the bucket empty
+	}
 	tb.cfg.Capacity = cfg.Limit
 	tb.cfg.RefillRate = cfg.Limit / int(cfg.Window.Seconds()) // approximate refill rate
 	tb.cfg.RefillEvery = cfg.Window
@@ -91,25 +156,67 @@ func (tb *TokenBucket) UpdateConfig(cfg Config) {
 
 // GetState returns the current state of the token bucket for a given key
 func (tb *TokenBucket) GetState(key string) bucketState {
-	raw, _ := tb.store.Get(key)
-	if raw == nil {
+	raw, _ := tb.store.Get(stateKey(tb.cfg.Name, key))
+	state, ok := raw.(bucketState)
+	if !ok {
 		return bucketState{
 			Tokens:     tb.cfg.Capacity,
 			LastRefill: time.Now(),
 		}
 	}
-	return raw.(bucketState)
+	return state
+}
+
+// KeyState implements KeyManager.
+func (tb *TokenBucket) KeyState(key string) (KeyState, bool) {
+	raw, found := tb.store.Get(stateKey(tb.cfg.Name, key))
+	return tb.keyState(key, tb.refill(raw, time.Now())), found
+}
+
+// ResetKey implements KeyManager, giving the key a full bucket again.
+func (tb *TokenBucket) ResetKey(key string) {
+	tb.store.Delete(stateKey(tb.cfg.Name, key))
+}
+
+// GrantKey implements KeyManager. Granted tokens may exceed capacity until
+// the next refill.
+func (tb *TokenBucket) GrantKey(key string, n int) KeyState {
+	v := tb.store.Update(stateKey(tb.cfg.Name, key), tb.cfg.TTL, func(old interface{}, _ bool) interface{} {
+		state := tb.refill(old, time.Now())
+		state.Tokens += n
+		return state
+	})
+	return tb.keyState(key, v.(bucketState))
+}
+
+func (tb *TokenBucket) keyState(key string, state bucketState) KeyState {
+	return KeyState{
+		Key:       key,
+		Limit:     tb.cfg.Capacity,
+		Remaining: state.Tokens,
+		ResetAt:   state.LastRefill.Add(tb.cfg.RefillEvery),
+	}
 }
 
 func (tb *TokenBucket) GetConfig() TokenBucketConfig {
 	return tb.cfg
 }
 
-func (tb *TokenBucket) StoreSnapshot() map[string]interface{} {
-	result := make(map[string]interface{})
-	data := tb.store.Snapshot()
-	for k, v := range data {
-		result[k] = v
+// Definition implements Describer.
+func (tb *TokenBucket) Definition() Definition {
+	return Definition{
+		Name: tb.cfg.Name,
+		Type: "token-bucket",
+		Spec: &TokenBucketSpec{
+			Capacity:    tb.cfg.Capacity,
+			RefillRate:  tb.cfg.RefillRate,
+			RefillEvery: int(tb.cfg.RefillEvery / time.Second),
+			TTL:         int(tb.cfg.TTL / time.Second),
+		},
 	}
-	return result
+}
+
+// StoreSnapshot returns this bucket's stored entries keyed by client key.
+func (tb *TokenBucket) StoreSnapshot() map[string]interface{} {
+	return storeSnapshot(tb.store, tb.cfg.Name)
 }
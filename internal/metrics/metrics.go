@@ -0,0 +1,155 @@
+// Package metrics exposes the service's Prometheus metrics. Labels are
+// limited to configured limiter names, policy routes and endpoints; client
+// IDs are never used as labels, since they are unbounded.
+package metrics
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	"fibre_rate_limit_service/internal/config"
+	"fibre_rate_limit_service/internal/decision"
+	"fibre_rate_limit_service/internal/storage"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/middleware/adaptor"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// Metrics holds the collectors and the registry they are served from.
+type Metrics struct {
+	reg *prometheus.Registry
+
+	limiterDecisions *prometheus.CounterVec
+	policyDecisions  *prometheus.CounterVec
+	checkDuration    *prometheus.HistogramVec
+	sweepDuration    prometheus.Histogram
+	evictions        prometheus.Counter
+}
+
+// New creates the metrics and registers them with a fresh registry, along
+// with the Go runtime and process collectors.
+func New() *Metrics {
+	m := &Metrics{
+		reg: prometheus.NewRegistry(),
+		limiterDecisions: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "ratelimit_limiter_decisions_total",
+			Help: "Requests checked against a limiter, by limiter, mode (enforce or shadow) and result (allowed or denied).",
+		}, []string{"limiter", "mode", "result"}),
+		policyDecisions: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "ratelimit_policy_decisions_total",
+			Help: "Requests evaluated against a route's policy rules, by route, mode and result. Shadow series count requests shadow rules would have denied.",
+		}, []string{"route", "mode", "result"}),
+		checkDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "ratelimit_check_duration_seconds",
+			Help:    "Time to answer a check request, by endpoint.",
+			Buckets: []float64{.0001, .00025, .0005, .001, .0025, .005, .01, .025, .05, .1},
+		}, []string{"endpoint"}),
+		sweepDuration: prometheus.NewHistogram(prometheus.HistogramOpts{
+			Name:    "ratelimit_storage_sweep_duration_seconds",
+			Help:    "Time taken by each storage janitor sweep.",
+			Buckets: prometheus.ExponentialBuckets(.0001, 4, 10),
+		}),
+		evictions: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "ratelimit_storage_evictions_total",
+			Help: "Expired entries removed by the storage janitor.",
+		}),
+	}
+
+	m.reg.MustRegister(
+		m.limiterDecisions,
+		m.policyDecisions,
+		m.checkDuration,
+		m.sweepDuration,
+		m.evictions,
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+	)
+	return m
+}
+
+// Registry returns the registry the metrics are served from.
+func (m *Metrics) Registry() *prometheus.Registry {
+	return m.reg
+}
+
+// Instrument counts d's decisions and reports store's shard sizes, janitor
+// sweep durations and evictions.
+func (m *Metrics) Instrument(d *decision.Decider, store *storage.ShardedMap) {
+	d.Observe(m.ObserveDecision)
+	store.OnSweep(m.ObserveSweep)
+	m.reg.MustRegister(&shardCollector{store: store})
+}
+
+// ObserveDecision is a decision.Observer counting limiter and policy results.
+func (m *Metrics) ObserveDecision(req decision.Request, d decision.Decision) {
+	// The vectors keep the label strings of new series, and the request's
+	// may be reused once the handler returns.
+	if d.Policy.HasRules {
+		route := strings.Clone(req.Route)
+		m.policyDecisions.WithLabelValues(route, string(config.ModeEnforce), result(d.Policy.Allowed)).Inc()
+		if d.Policy.ShadowDenied {
+			m.policyDecisions.WithLabelValues(route, string(config.ModeShadow), result(false)).Inc()
+		}
+	}
+	if d.Limited {
+		mode := config.ModeEnforce
+		if d.Mode.Shadow() {
+			mode = config.ModeShadow
+		}
+		m.limiterDecisions.WithLabelValues(strings.Clone(d.Limiter), string(mode), result(d.Result.Allowed)).Inc()
+	}
+}
+
+// ObserveSweep is a storage.SweepObserver.
+func (m *Metrics) ObserveSweep(took time.Duration, evicted int) {
+	m.sweepDuration.Observe(took.Seconds())
+	m.evictions.Add(float64(evicted))
+}
+
+// Timer records how long the handlers after it take under endpoint.
+func (m *Metrics) Timer(endpoint string) fiber.Handler {
+	h := m.checkDuration.WithLabelValues(endpoint)
+	return func(c *fiber.Ctx) error {
+		start := time.Now()
+		err := c.Next()
+		h.Observe(time.Since(start).Seconds())
+		return err
+	}
+}
+
+// Handler serves the metrics in the Prometheus exposition format.
+func (m *Metrics) Handler() fiber.Handler {
+	return adaptor.HTTPHandler(promhttp.HandlerFor(m.reg, promhttp.HandlerOpts{}))
+}
+
+func result(allowed bool) string {
+	if allowed {
+		return "allowed"
+	}
+	return "denied"
+}
+
+var shardEntriesDesc = prometheus.NewDesc(
+	"ratelimit_storage_shard_entries",
+	"Entries held in each storage shard, including expired ones not yet swept.",
+	[]string{"shard"}, nil,
+)
+
+// shardCollector reads the shard sizes at scrape time.
+type shardCollector struct {
+	store *storage.ShardedMap
+}
+
+func (c *shardCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- shardEntriesDesc
+}
+
+func (c *shardCollector) Collect(ch chan<- prometheus.Metric) {
+	for i, n := range c.store.ShardLens() {
+		ch <- prometheus.MustNewConstMetric(shardEntriesDesc, prometheus.GaugeValue, float64(n), strconv.Itoa(i))
+	}
+}
@@ -0,0 +1,76 @@
+package metrics_test
+
+import (
+	"io"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"fibre_rate_limit_service/internal/decision"
+	apphttp "fibre_rate_limit_service/internal/http"
+	"fibre_rate_limit_service/internal/limiters"
+	"fibre_rate_limit_service/internal/metrics"
+	"fibre_rate_limit_service/internal/policies"
+	"fibre_rate_limit_service/internal/storage"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func TestMetricsEndpoint(t *testing.T) {
+	store := storage.NewShardedMap(2, time.Minute, time.Minute)
+	t.Cleanup(store.Close)
+
+	lm := limiters.NewManager()
+	lm.AddLimiter(limiters.NewTokenBucket(limiters.TokenBucketConfig{
+		Name: "/check", Capacity: 1, RefillRate: 1, RefillEvery: time.Minute, TTL: time.Minute,
+	}, store))
+	pe := policies.NewEvaluator()
+	pe.AddRule("/check", policies.Rule{Header: "X-Secret", Value: "123"})
+	d := decision.NewDecider(lm, pe)
+
+	m := metrics.New()
+	m.Instrument(d, store)
+	m.ObserveSweep(2*time.Millisecond, 3)
+
+	app := fiber.New()
+	apphttp.SetupCheckRoutes(app, apphttp.Services{Limiters: lm, Policies: pe, Store: store, Decider: d, Metrics: m})
+
+	check := func(secret string) {
+		req := httptest.NewRequest("POST", "/check", nil)
+		req.Header.Set("X-Client-ID", "alice")
+		req.Header.Set("X-Secret", secret)
+		if _, err := app.Test(req); err != nil {
+			t.Fatal(err)
+		}
+	}
+	check("123") // allowed
+	check("123") // rate limited
+	check("bad") // policy denied
+
+	resp, err := app.Test(httptest.NewRequest("GET", "/metrics", nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	raw, _ := io.ReadAll(resp.Body)
+	body := string(raw)
+
+	for _, want := range []string{
+		`ratelimit_limiter_decisions_total{limiter="/check",mode="enforce",result="allowed"} 1`,
+		`ratelimit_limiter_decisions_total{limiter="/check",mode="enforce",result="denied"} 1`,
+		`ratelimit_policy_decisions_total{mode="enforce",result="allowed",route="/check"} 2`,
+		`ratelimit_policy_decisions_total{mode="enforce",result="denied",route="/check"} 1`,
+		`ratelimit_check_duration_seconds_count{endpoint="check"} 3`,
+		`ratelimit_storage_sweep_duration_seconds_count 1`,
+		`ratelimit_storage_evictions_total 3`,
+		`ratelimit_storage_shard_entries{shard="0"}`,
+		`ratelimit_storage_shard_entries{shard="1"}`,
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("metrics missing %q", want)
+		}
+	}
+	if strings.Contains(body, "alice") {
+		t.Error("metrics expose a client ID")
+	}
+}
@@ -1,26 +1,41 @@
 package policies
 
 import (
-	"github.com/gofiber/fiber/v2"
+	"sort"
+	"strconv"
+	"strings"
 	"sync"
+
+	"fibre_rate_limit_service/internal/config"
 )
 
 // Result represents the outcome of policy evaluation
 type Result struct {
-	Allowed bool
-	Reason  string
+	Allowed  bool
+	Reason   string
+	Missing  bool // the failing rule's header was absent
+	HasRules bool // the route has rules; false means allowed by default
+
+	// ShadowDenied is set when a rule in shadow mode failed; it doesn't
+	// affect Allowed. ShadowReason describes the first such rule.
+	ShadowDenied bool
+	ShadowReason string
 }
 
-// Rule defines a simple header-based rule
+// Rule defines a simple header-based rule. A rule in shadow mode is
+// evaluated but never rejects requests.
 type Rule struct {
-	Header string
-	Value  string
+	ID     string      `json:"id"`
+	Header string      `json:"header"`
+	Value  string      `json:"value"`
+	Mode   config.Mode `json:"mode,omitempty"`
 }
 
 // Evaluator stores all rules for routes
 type Evaluator struct {
-	mu    sync.RWMutex
-	rules map[string][]Rule // route -> list of rules
+	mu     sync.RWMutex
+	rules  map[string][]Rule // route -> list of rules
+	nextID uint64
 }
 
 // NewEvaluator creates a new evaluator
@@ -30,33 +45,220 @@ func NewEvaluator() *Evaluator {
 	}
 }
 
-// AddRule adds a rule for a route
-func (e *Evaluator) AddRule(route string, r Rule) {
+// AddRule adds a rule for a route and returns it with its ID.
+// A rule with the same ID, or for the same header, is replaced rather than
+// duplicated, since a header can only ever equal one value.
+func (e *Evaluator) AddRule(route string, r Rule) Rule {
 	e.mu.Lock()
 	defer e.mu.Unlock()
-	e.rules[route] = append(e.rules[route], r)
+
+	rules := e.rules[route]
+	for i, existing := range rules {
+		if (r.ID != "" && existing.ID == r.ID) ||
+			(r.ID == "" && strings.EqualFold(existing.Header, r.Header)) {
+			if r.ID == "" {
+				r.ID = existing.ID
+			}
+			rules[i] = r
+			return r
+		}
+	}
+
+	if r.ID == "" {
+		r.ID = e.newID()
+	}
+	e.rules[route] = append(rules, r)
+	return r
 }
 
 func (e *Evaluator) SetRule(route string, rule Rule) {
+	e.SetRules(route, []Rule{rule})
+}
+
+// SetRules atomically replaces every rule of a route. Rules without an ID,
+// or repeating an earlier rule's ID, are assigned one; an empty list removes
+// the route.
+func (e *Evaluator) SetRules(route string, rules []Rule) []Rule {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if len(rules) == 0 {
+		delete(e.rules, route)
+		return nil
+	}
+
+	out := e.assignIDs(rules, nil)
+	e.rules[route] = out
+	return append([]Rule(nil), out...)
+}
+
+// ReplaceRoutes sets the rules of several routes and removes others in one
+// step. Rules without an ID keep the ID of the route's existing rule for the
+// same header, unless another rule already has it, or are assigned a new one.
+func (e *Evaluator) ReplaceRoutes(set map[string][]Rule, remove []string) {
 	e.mu.Lock()
 	defer e.mu.Unlock()
-	e.rules[route] = []Rule{rule} // ✅ wrap in slice
+
+	for _, route := range remove {
+		delete(e.rules, route)
+	}
+	for route, rules := range set {
+		if len(rules) == 0 {
+			delete(e.rules, route)
+			continue
+		}
+		e.rules[route] = e.assignIDs(rules, e.rules[route])
+	}
+}
+
+// assignIDs returns rules with distinct IDs. A rule keeps its own ID unless
+// an earlier rule has it; rules left without one take the ID of an unused
+// old rule for the same header, or a new one. Callers must hold e.mu.
+func (e *Evaluator) assignIDs(rules, old []Rule) []Rule {
+	out := make([]Rule, len(rules))
+	used := make(map[string]bool, len(rules))
+	for i, r := range rules {
+		if used[r.ID] {
+			r.ID = ""
+		}
+		if r.ID != "" {
+			used[r.ID] = true
+		}
+		out[i] = r
+	}
+
+	for i := range out {
+		if out[i].ID != "" {
+			continue
+		}
+		for _, o := range old {
+			if !used[o.ID] && strings.EqualFold(o.Header, out[i].Header) {
+				out[i].ID = o.ID
+				break
+			}
+		}
+		for out[i].ID == "" {
+			if id := e.newID(); !used[id] {
+				out[i].ID = id
+			}
+		}
+		used[out[i].ID] = true
+	}
+	return out
+}
+
+// Rules returns a copy of a route's rules.
+func (e *Evaluator) Rules(route string) []Rule {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	return append([]Rule(nil), e.rules[route]...)
+}
+
+// Routes returns every route with rules, sorted.
+func (e *Evaluator) Routes() []string {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+
+	routes := make([]string, 0, len(e.rules))
+	for route := range e.rules {
+		routes = append(routes, route)
+	}
+	sort.Strings(routes)
+	return routes
+}
+
+// GetRule returns a single rule by ID.
+func (e *Evaluator) GetRule(route, id string) (Rule, bool) {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+
+	for _, r := range e.rules[route] {
+		if r.ID == id {
+			return r, true
+		}
+	}
+	return Rule{}, false
+}
+
+// ReplaceRule updates the rule with the given ID, reporting whether it existed.
+func (e *Evaluator) ReplaceRule(route, id string, r Rule) bool {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	rules := e.rules[route]
+	for i := range rules {
+		if rules[i].ID == id {
+			r.ID = id
+			rules[i] = r
+			return true
+		}
+	}
+	return false
+}
+
+// DeleteRule removes the rule with the given ID, reporting whether it existed.
+func (e *Evaluator) DeleteRule(route, id string) bool {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	rules := e.rules[route]
+	for i := range rules {
+		if rules[i].ID == id {
+			rest := append(append([]Rule(nil), rules[:i]...), rules[i+1:]...)
+			if len(rest) == 0 {
+				delete(e.rules, route)
+			} else {
+				e.rules[route] = rest
+			}
+			return true
+		}
+	}
+	return false
+}
+
+// newID returns an unused rule ID. Callers must hold e.mu.
+func (e *Evaluator) newID() string {
+	for {
+		e.nextID++
+		id := "rule-" + strconv.FormatUint(e.nextID, 10)
+		if !e.hasID(id) {
+			return id
+		}
+	}
+}
+
+func (e *Evaluator) hasID(id string) bool {
+	for _, rules := range e.rules {
+		for _, r := range rules {
+			if r.ID == id {
+				return true
+			}
+		}
+	}
+	return false
 }
 
 // Evaluate checks the rules for a client request
-func (e *Evaluator) Evaluate(clientID string, route string, c *fiber.Ctx) Result {
+func (e *Evaluator) Evaluate(clientID string, route string, h Headers) Result {
 	e.mu.RLock()
 	defer e.mu.RUnlock()
 
 	rules := e.rules[route]
+	res := Result{Allowed: true, HasRules: len(rules) > 0}
 	for _, r := range rules {
-		if c.Get(r.Header) != r.Value {
-			return Result{
-				Allowed: false,
-				Reason:  "Header " + r.Header + " must equal " + r.Value,
+		v := h.Get(r.Header)
+		if v == r.Value {
+			continue
+		}
+		reason := "Header " + r.Header + " must equal " + r.Value
+		if r.Mode.Shadow() {
+			if !res.ShadowDenied {
+				res.ShadowDenied, res.ShadowReason = true, reason
 			}
+			continue
 		}
+		res.Allowed, res.Reason, res.Missing = false, reason, v == ""
+		return res
 	}
-
-	return Result{Allowed: true}
+	return res
 }
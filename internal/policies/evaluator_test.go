@@ -0,0 +1,44 @@
+package policies
+
+import "testing"
+
+func ruleIDs(rules []Rule) map[string]bool {
+	ids := make(map[string]bool, len(rules))
+	for _, r := range rules {
+		ids[r.ID] = true
+	}
+	return ids
+}
+
+func TestEvaluator_ReplaceRoutesKeepsIDsUnique(t *testing.T) {
+	e := NewEvaluator()
+	old := e.AddRule("/orders", Rule{Header: "X-Tenant", Value: "a"})
+
+	e.ReplaceRoutes(map[string][]Rule{"/orders": {
+		{Header: "X-Tenant", Value: "a"},
+		{Header: "x-tenant", Value: "b"},
+		{ID: "rule-2", Header: "X-Plan", Value: "pro"},
+	}}, nil)
+
+	rules := e.Rules("/orders")
+	if len(ruleIDs(rules)) != len(rules) {
+		t.Fatalf("duplicate rule IDs: %+v", rules)
+	}
+	if rules[0].ID != old.ID {
+		t.Fatalf("first rule for the header should keep %q, got %q", old.ID, rules[0].ID)
+	}
+	if rules[2].ID != "rule-2" {
+		t.Fatalf("explicit ID replaced: %q", rules[2].ID)
+	}
+}
+
+func TestEvaluator_SetRulesReassignsDuplicateIDs(t *testing.T) {
+	e := NewEvaluator()
+	rules := e.SetRules("/orders", []Rule{
+		{ID: "a", Header: "X-Tenant", Value: "a"},
+		{ID: "a", Header: "X-Plan", Value: "pro"},
+	})
+	if rules[0].ID != "a" || rules[1].ID == "a" || rules[1].ID == "" {
+		t.Fatalf("unexpected IDs: %+v", rules)
+	}
+}
@@ -0,0 +1,15 @@
+package policies
+
+// Headers gives read access to request headers independent of the transport
+// the request arrived on.
+type Headers interface {
+	Get(name string) string
+}
+
+// HeaderFunc adapts a plain lookup function to the Headers interface.
+type HeaderFunc func(name string) string
+
+// Get returns the value of the named header.
+func (f HeaderFunc) Get(name string) string {
+	return f(name)
+}
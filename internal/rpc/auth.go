@@ -0,0 +1,69 @@
+package rpc
+
+import (
+	"context"
+	"strings"
+
+	"fibre_rate_limit_service/internal/config"
+	"fibre_rate_limit_service/internal/http/middleware"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// adminRoles are the roles the admin RPCs require. Check and CheckBatch are
+// open, like POST /check.
+var adminRoles = map[string]middleware.Role{
+	"/ratelimit.v1.RateLimiter/SetLimiter": middleware.RoleAdmin,
+	"/ratelimit.v1.RateLimiter/AddPolicy":  middleware.RoleAdmin,
+	"/ratelimit.v1.RateLimiter/Snapshot":   middleware.RoleReadOnly,
+}
+
+// AdminInterceptor enforces admin roles on the admin RPCs, using the bearer
+// tokens of a (sent as "authorization: Bearer <token>" metadata). A nil a
+// allows every call.
+//
+// HMAC signatures cover an HTTP method, path and body, which gRPC calls
+// don't have in that form, so calls sending one are rejected with a message
+// saying to use a bearer token; HMAC keys only authenticate the HTTP API.
+func AdminInterceptor(a *middleware.AdminAuth) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		role, ok := adminRoles[info.FullMethod]
+		if a == nil || !ok {
+			return handler(ctx, req)
+		}
+
+		md, _ := metadata.FromIncomingContext(ctx)
+		var token string
+		for _, v := range md.Get("authorization") {
+			switch scheme, cred, _ := strings.Cut(v, " "); {
+			case strings.EqualFold(scheme, "bearer"):
+				token = cred
+			case strings.EqualFold(scheme, "hmac"):
+				return nil, status.Error(codes.Unauthenticated, "HMAC signatures are not supported over gRPC; use a bearer token")
+			}
+		}
+		p, ok := a.Token(token)
+		if !ok {
+			return nil, status.Error(codes.Unauthenticated, "authentication required")
+		}
+		if p.Role < role {
+			return nil, status.Error(codes.PermissionDenied, "requires the "+role.String()+" role")
+		}
+		return handler(context.WithValue(ctx, principalKey{}, p), req)
+	}
+}
+
+// principalKey is the context key holding the caller's middleware.Principal.
+type principalKey struct{}
+
+// actor names who made an admin call: the authenticated principal, or the
+// caller's IP address when admin auth is off.
+func actor(ctx context.Context) string {
+	if p, ok := ctx.Value(principalKey{}).(middleware.Principal); ok {
+		return p.Name
+	}
+	return config.SafeString(peerIP(ctx), "grpc")
+}
@@ -0,0 +1,62 @@
+package rpc
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"fibre_rate_limit_service/internal/http/middleware"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"gopkg.in/yaml.v3"
+)
+
+func TestAdminInterceptor(t *testing.T) {
+	var cfg middleware.AdminAuthConfig
+	err := yaml.Unmarshal([]byte(`
+tokens:
+  - {name: viewer, role: read-only, token: view-token}
+  - {name: alice, role: admin, token: admin-token}
+hmac_keys:
+  - {name: bot, role: admin, key_id: bot, secret: s3cret}
+`), &cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	auth, err := middleware.NewAdminAuth(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	intercept := AdminInterceptor(auth)
+	info := &grpc.UnaryServerInfo{FullMethod: "/ratelimit.v1.RateLimiter/SetLimiter"}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return actor(ctx), nil
+	}
+
+	cases := []struct {
+		name, authz string
+		code        codes.Code
+		msg         string
+	}{
+		{"no credentials", "", codes.Unauthenticated, "authentication required"},
+		{"admin token", "Bearer admin-token", codes.OK, ""},
+		{"read-only token", "Bearer view-token", codes.PermissionDenied, "admin role"},
+		{"hmac", "HMAC bot:1700000000:abcd", codes.Unauthenticated, "use a bearer token"},
+	}
+	for _, tc := range cases {
+		ctx := context.Background()
+		if tc.authz != "" {
+			ctx = metadata.NewIncomingContext(ctx, metadata.Pairs("authorization", tc.authz))
+		}
+		got, err := intercept(ctx, nil, info, handler)
+		if st := status.Convert(err); st.Code() != tc.code || !strings.Contains(st.Message(), tc.msg) {
+			t.Errorf("%s: got %v, want %v containing %q", tc.name, err, tc.code, tc.msg)
+		}
+		if tc.code == codes.OK && got != "alice" {
+			t.Errorf("%s: actor %v, want alice", tc.name, got)
+		}
+	}
+}
@@ -0,0 +1,11 @@
+// Package rpc serves the RateLimiter gRPC API defined in
+// proto/ratelimit/v1/ratelimit.proto.
+//
+// Check and CheckBatch cover what POST /check does. The admin RPCs are a
+// deliberately small subset of the HTTP admin API, for clients that only
+// provision limiters and rules: SetLimiter, AddPolicy and Snapshot. Every
+// other admin operation is HTTP only, and admin RPCs authenticate with
+// bearer tokens only (see AdminInterceptor).
+package rpc
+
+//go:generate protoc -I ../../proto --go_out=ratelimitv1 --go_opt=paths=source_relative --go-grpc_out=ratelimitv1 --go-grpc_opt=paths=source_relative ratelimit/v1/ratelimit.proto
@@ -0,0 +1,269 @@
+package rpc
+
+import (
+	"context"
+	"encoding/json"
+	"net"
+	"net/http"
+
+	"fibre_rate_limit_service/internal/audit"
+	"fibre_rate_limit_service/internal/config"
+	"fibre_rate_limit_service/internal/config/configfile"
+	"fibre_rate_limit_service/internal/decision"
+	"fibre_rate_limit_service/internal/limiters"
+	"fibre_rate_limit_service/internal/policies"
+	"fibre_rate_limit_service/internal/rpc/ratelimitv1"
+	"fibre_rate_limit_service/internal/storage"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/structpb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// Server implements ratelimitv1.RateLimiterServer on top of the same
+// limiters and policies used by the HTTP API.
+type Server struct {
+	ratelimitv1.UnimplementedRateLimiterServer
+
+	lm    *limiters.Manager
+	pe    *policies.Evaluator
+	store *storage.ShardedMap
+	d     *decision.Decider
+
+	// History, if set, records a config version for each admin change.
+	History *configfile.History
+	// Audit, if set, records each admin change.
+	Audit *audit.Log
+}
+
+// NewServer creates a gRPC rate limiter service. d should be the decider the
+// HTTP API uses, so both identify clients the same way.
+func NewServer(d *decision.Decider, lm *limiters.Manager, pe *policies.Evaluator, store *storage.ShardedMap) *Server {
+	return &Server{
+		lm:    lm,
+		pe:    pe,
+		store: store,
+		d:     d,
+	}
+}
+
+// Register attaches the service to a gRPC server.
+func (s *Server) Register(gs *grpc.Server) {
+	ratelimitv1.RegisterRateLimiterServer(gs, s)
+}
+
+// Check evaluates policies and the route's limiter for one request.
+func (s *Server) Check(ctx context.Context, req *ratelimitv1.CheckRequest) (*ratelimitv1.CheckResponse, error) {
+	if req.GetRoute() == "" {
+		return nil, status.Error(codes.InvalidArgument, "route is required")
+	}
+	return s.check(ctx, req), nil
+}
+
+// CheckBatch evaluates several requests in order.
+func (s *Server) CheckBatch(ctx context.Context, req *ratelimitv1.CheckBatchRequest) (*ratelimitv1.CheckBatchResponse, error) {
+	out := &ratelimitv1.CheckBatchResponse{
+		Responses: make([]*ratelimitv1.CheckResponse, 0, len(req.GetRequests())),
+	}
+	for i, r := range req.GetRequests() {
+		if r.GetRoute() == "" {
+			return nil, status.Errorf(codes.InvalidArgument, "requests[%d]: route is required", i)
+		}
+		out.Responses = append(out.Responses, s.check(ctx, r))
+	}
+	return out, nil
+}
+
+func (s *Server) check(ctx context.Context, req *ratelimitv1.CheckRequest) *ratelimitv1.CheckResponse {
+	headers := make(http.Header, len(req.GetHeaders()))
+	for k, v := range req.GetHeaders() {
+		headers.Set(k, v)
+	}
+
+	clientID := req.GetClientId()
+	if clientID == "" {
+		clientID = s.d.ClientID(headers, peerIP(ctx))
+	}
+
+	res := s.d.DecideContext(ctx, decision.Request{
+		ID:       requestID(ctx),
+		ClientID: clientID,
+		Route:    req.GetRoute(),
+		Method:   req.GetMethod(),
+		Headers:  headers,
+	})
+
+	out := &ratelimitv1.CheckResponse{
+		Allowed: res.Allowed(),
+		Outcome: outcome(res.Outcome),
+		Reason:  res.Reason,
+		Limited: res.Limited,
+	}
+	if res.Limited {
+		out.Limit = int32(res.Result.Limit)
+		out.Remaining = int32(res.Result.Remaining)
+		out.ResetAt = timestamppb.New(res.Result.ResetAt)
+	}
+	return out
+}
+
+// commit records a config version attributed to the caller.
+func (s *Server) commit(ctx context.Context, action string) {
+	if s.History != nil {
+		s.History.Commit(actor(ctx), action)
+	}
+}
+
+// record writes an admin change to the audit log.
+func (s *Server) record(ctx context.Context, action, target string, before, after interface{}) {
+	if s.Audit == nil {
+		return
+	}
+	if err := s.Audit.Record(audit.Entry{
+		Action:    action,
+		Target:    target,
+		Actor:     actor(ctx),
+		SourceIP:  peerIP(ctx),
+		RequestID: requestID(ctx),
+		Before:    before,
+		After:     after,
+	}); err != nil {
+		config.Logger.Error("audit log error", "err", err)
+	}
+}
+
+// requestID returns the caller's x-request-id metadata, if any.
+func requestID(ctx context.Context) string {
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		if ids := md.Get("x-request-id"); len(ids) > 0 {
+			return ids[0]
+		}
+	}
+	return ""
+}
+
+// peerIP returns the caller's IP address, if known.
+func peerIP(ctx context.Context) string {
+	p, ok := peer.FromContext(ctx)
+	if !ok || p.Addr == nil {
+		return ""
+	}
+	host, _, err := net.SplitHostPort(p.Addr.String())
+	if err != nil {
+		return p.Addr.String()
+	}
+	return host
+}
+
+func outcome(o decision.Outcome) ratelimitv1.Outcome {
+	switch o {
+	case decision.Allowed:
+		return ratelimitv1.Outcome_OUTCOME_ALLOWED
+	case decision.PolicyDenied:
+		return ratelimitv1.Outcome_OUTCOME_POLICY_DENIED
+	case decision.RateLimited:
+		return ratelimitv1.Outcome_OUTCOME_RATE_LIMITED
+	default:
+		return ratelimitv1.Outcome_OUTCOME_UNSPECIFIED
+	}
+}
+
+// SetLimiter adds or replaces a limiter.
+func (s *Server) SetLimiter(ctx context.Context, req *ratelimitv1.SetLimiterRequest) (*ratelimitv1.AdminResponse, error) {
+	// Go through the same flat JSON form as the HTTP API so every registered
+	// limiter type is supported.
+	raw, err := json.Marshal(map[string]interface{}{
+		"name":         req.GetName(),
+		"type":         req.GetType(),
+		"capacity":     req.GetCapacity(),
+		"refill_rate":  req.GetRefillRate(),
+		"refill_every": req.GetRefillEvery(),
+		"ttl":          req.GetTtl(),
+		"limit":        req.GetLimit(),
+		"window":       req.GetWindow(),
+		"mode":         req.GetMode(),
+	})
+	if err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+
+	var def limiters.Definition
+	if err := json.Unmarshal(raw, &def); err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	var before, after limiters.Definition
+	var existed bool
+	err = s.d.Update(func() error {
+		before, existed = s.lm.Definition(def.Name)
+		if err := s.lm.Apply(def, s.store); err != nil {
+			return err
+		}
+		after, _ = s.lm.Definition(def.Name)
+		return nil
+	})
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	var prev interface{}
+	if existed {
+		prev = before
+	}
+	s.record(ctx, "limiter.set", def.Name, prev, after)
+	s.commit(ctx, "SetLimiter "+req.GetName())
+	return &ratelimitv1.AdminResponse{Message: "limiter added/updated successfully"}, nil
+}
+
+// AddPolicy adds a policy rule to a route.
+func (s *Server) AddPolicy(ctx context.Context, req *ratelimitv1.AddPolicyRequest) (*ratelimitv1.AdminResponse, error) {
+	if req.GetRoute() == "" || req.GetHeader() == "" {
+		return nil, status.Error(codes.InvalidArgument, "route and header are required")
+	}
+	mode := config.Mode(req.GetMode())
+	if err := mode.Validate(); err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	var before, after []policies.Rule
+	err := s.d.Update(func() error {
+		before = s.pe.Rules(req.GetRoute())
+		s.pe.AddRule(req.GetRoute(), policies.Rule{
+			Header: req.GetHeader(),
+			Value:  req.GetValue(),
+			Mode:   mode,
+		})
+		after = s.pe.Rules(req.GetRoute())
+		return nil
+	})
+	if err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+
+	var prev interface{}
+	if len(before) > 0 {
+		prev = before
+	}
+	s.record(ctx, "policy.add", req.GetRoute(), prev, after)
+	s.commit(ctx, "AddPolicy "+req.GetRoute())
+	return &ratelimitv1.AdminResponse{Message: "policy added/updated successfully"}, nil
+}
+
+// Snapshot returns the limiters' in-memory state.
+func (s *Server) Snapshot(ctx context.Context, req *ratelimitv1.SnapshotRequest) (*ratelimitv1.SnapshotResponse, error) {
+	// Round-trip through JSON so the document matches GET /admin/snapshot.
+	raw, err := json.Marshal(s.lm.Snapshot())
+	if err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+
+	snap := &structpb.Struct{}
+	if err := snap.UnmarshalJSON(raw); err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+
+	return &ratelimitv1.SnapshotResponse{Snapshot: snap}, nil
+}
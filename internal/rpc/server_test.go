@@ -0,0 +1,118 @@
+package rpc
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"fibre_rate_limit_service/internal/config"
+	"fibre_rate_limit_service/internal/decision"
+	"fibre_rate_limit_service/internal/limiters"
+	"fibre_rate_limit_service/internal/policies"
+	"fibre_rate_limit_service/internal/rpc/ratelimitv1"
+	"fibre_rate_limit_service/internal/storage"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestServer_CheckBatch(t *testing.T) {
+	store := storage.NewShardedMap(4, time.Minute, time.Minute)
+	defer store.Close()
+
+	lm, pe := limiters.NewManager(), policies.NewEvaluator()
+	srv := NewServer(decision.NewDecider(lm, pe), lm, pe, store)
+	ctx := context.Background()
+
+	if _, err := srv.SetLimiter(ctx, &ratelimitv1.SetLimiterRequest{
+		Name:        "/orders",
+		Type:        "token-bucket",
+		Capacity:    1,
+		RefillRate:  1,
+		RefillEvery: 3600,
+	}); err != nil {
+		t.Fatalf("SetLimiter: %v", err)
+	}
+	if _, err := srv.AddPolicy(ctx, &ratelimitv1.AddPolicyRequest{
+		Route: "/orders", Header: "X-Secret", Value: "123",
+	}); err != nil {
+		t.Fatalf("AddPolicy: %v", err)
+	}
+
+	req := &ratelimitv1.CheckRequest{
+		ClientId: "c1",
+		Route:    "/orders",
+		Headers:  map[string]string{"x-secret": "123"},
+	}
+	resp, err := srv.CheckBatch(ctx, &ratelimitv1.CheckBatchRequest{
+		Requests: []*ratelimitv1.CheckRequest{
+			{ClientId: "c1", Route: "/orders"},
+			req,
+			req,
+		},
+	})
+	if err != nil {
+		t.Fatalf("CheckBatch: %v", err)
+	}
+
+	want := []ratelimitv1.Outcome{
+		ratelimitv1.Outcome_OUTCOME_POLICY_DENIED,
+		ratelimitv1.Outcome_OUTCOME_ALLOWED,
+		ratelimitv1.Outcome_OUTCOME_RATE_LIMITED,
+	}
+	for i, r := range resp.GetResponses() {
+		if r.GetOutcome() != want[i] {
+			t.Fatalf("response %d: expected %v, got %v", i, want[i], r.GetOutcome())
+		}
+	}
+
+	snap, err := srv.Snapshot(ctx, &ratelimitv1.SnapshotRequest{})
+	if err != nil {
+		t.Fatalf("Snapshot: %v", err)
+	}
+	if _, ok := snap.GetSnapshot().GetFields()["/orders"]; !ok {
+		t.Fatalf("expected /orders in snapshot, got %v", snap.GetSnapshot())
+	}
+}
+
+func TestServer_AdminModes(t *testing.T) {
+	store := storage.NewShardedMap(4, time.Minute, time.Minute)
+	defer store.Close()
+
+	lm, pe := limiters.NewManager(), policies.NewEvaluator()
+	srv := NewServer(decision.NewDecider(lm, pe), lm, pe, store)
+	ctx := context.Background()
+
+	if _, err := srv.SetLimiter(ctx, &ratelimitv1.SetLimiterRequest{
+		Name: "/orders", Type: "fixed-window", Limit: 1, Window: 60, Mode: "shadow",
+	}); err != nil {
+		t.Fatalf("SetLimiter: %v", err)
+	}
+	if m := lm.Mode("/orders"); m != config.ModeShadow {
+		t.Fatalf("limiter mode %q, want shadow", m)
+	}
+	if _, err := srv.AddPolicy(ctx, &ratelimitv1.AddPolicyRequest{
+		Route: "/orders", Header: "X-Secret", Value: "1", Mode: "shadow",
+	}); err != nil {
+		t.Fatalf("AddPolicy: %v", err)
+	}
+	if rules := pe.Rules("/orders"); len(rules) != 1 || rules[0].Mode != config.ModeShadow {
+		t.Fatalf("rules %+v, want one shadow rule", rules)
+	}
+
+	if _, err := srv.AddPolicy(ctx, &ratelimitv1.AddPolicyRequest{
+		Route: "/orders", Header: "X-Other", Mode: "audit",
+	}); status.Code(err) != codes.InvalidArgument {
+		t.Fatalf("AddPolicy with an unknown mode: %v, want InvalidArgument", err)
+	}
+	if _, err := srv.AddPolicy(ctx, &ratelimitv1.AddPolicyRequest{
+		Route: "/orders", Value: "1",
+	}); status.Code(err) != codes.InvalidArgument {
+		t.Fatalf("AddPolicy without a header: %v, want InvalidArgument", err)
+	}
+	if _, err := srv.SetLimiter(ctx, &ratelimitv1.SetLimiterRequest{
+		Name: "/orders", Type: "fixed-window", Limit: 1, Window: 60, Mode: "audit",
+	}); status.Code(err) != codes.InvalidArgument {
+		t.Fatalf("SetLimiter with an unknown mode: %v, want InvalidArgument", err)
+	}
+}
@@ -1,11 +1,16 @@
 package storage
 
 import (
+	"errors"
 	"hash/fnv"
 	"sync"
+	"sync/atomic"
 	"time"
 )
 
+// ErrClosed is returned by Ping once the store has been closed.
+var ErrClosed = errors.New("storage closed")
+
 func fnv32(key string) uint32 {
 	h := fnv.New32a()
 	h.Write([]byte(key))
@@ -59,10 +64,17 @@ type ShardedMap struct {
 	nShards    uint32
 	defaultTTL time.Duration
 
-	janitorStop chan struct{}
-	stopOnce    sync.Once
+	janitorStop    chan struct{}
+	janitorRunning atomic.Bool
+	stopOnce       sync.Once
+	closed         atomic.Bool
+	onSweep        atomic.Pointer[SweepObserver]
 }
 
+// SweepObserver is told how long each janitor sweep took and how many
+// expired entries it evicted.
+type SweepObserver func(took time.Duration, evicted int)
+
 // NewShardedMap initializes shards and starts janitor goroutine.
 func NewShardedMap(nShards int, defaultTTL time.Duration, cleanupInterval time.Duration) *ShardedMap {
 	if nShards <= 0 {
@@ -82,6 +94,7 @@ func NewShardedMap(nShards int, defaultTTL time.Duration, cleanupInterval time.D
 		}
 	}
 
+	s.janitorRunning.Store(true)
 	go s.janitor(cleanupInterval)
 
 	return s
@@ -96,21 +109,51 @@ func (s *ShardedMap) shardFor(key string) *shard {
 
 // Set writes a value with optional TTL (pass -1 for NO expiry)
 func (s *ShardedMap) Set(key string, val interface{}, ttl time.Duration) {
-	var exp time.Time
+	exp := s.expiry(ttl)
+
+	sh := s.shardFor(key)
+	sh.mu.Lock()
+	sh.m[key] = Entry{Value: val, ExpiresAt: exp}
+	sh.mu.Unlock()
+}
+
+// Update atomically replaces a key's value with fn(old, found) under the
+// shard lock, so concurrent read-modify-write cycles don't lose updates.
+// Expired values are passed as not found. TTL follows Set.
+func (s *ShardedMap) Update(key string, ttl time.Duration, fn func(old interface{}, found bool) interface{}) interface{} {
+	exp := s.expiry(ttl)
+	now := time.Now()
+
+	sh := s.shardFor(key)
+	sh.mu.Lock()
+	defer sh.mu.Unlock()
 
+	ent, found := sh.m[key]
+	if found && ent.isExpired(now) {
+		found = false
+	}
+
+	var old interface{}
+	if found {
+		old = ent.Value
+	}
+
+	val := fn(old, found)
+	sh.m[key] = Entry{Value: val, ExpiresAt: exp}
+	return val
+}
+
+// expiry converts a Set TTL into an absolute expiration time.
+func (s *ShardedMap) expiry(ttl time.Duration) time.Time {
 	switch {
 	case ttl == -1:
 		// No expiry
 	case ttl > 0:
-		exp = time.Now().Add(ttl)
+		return time.Now().Add(ttl)
 	case ttl == 0 && s.defaultTTL > 0:
-		exp = time.Now().Add(s.defaultTTL)
+		return time.Now().Add(s.defaultTTL)
 	}
-
-	sh := s.shardFor(key)
-	sh.mu.Lock()
-	sh.m[key] = Entry{Value: val, ExpiresAt: exp}
-	sh.mu.Unlock()
+	return time.Time{}
 }
 
 // Get reads a value and purges if expired.
@@ -164,6 +207,7 @@ func (s *ShardedMap) Snapshot() map[string]Entry {
 
 // janitor periodically removes expired keys.
 func (s *ShardedMap) janitor(interval time.Duration) {
+	defer s.janitorRunning.Store(false)
 	if interval <= 0 {
 		interval = time.Second * 10
 	}
@@ -174,15 +218,10 @@ func (s *ShardedMap) janitor(interval time.Duration) {
 	for {
 		select {
 		case <-ticker.C:
-			now := time.Now()
-			for _, sh := range s.shards {
-				sh.mu.Lock()
-				for k, v := range sh.m {
-					if v.isExpired(now) {
-						delete(sh.m, k)
-					}
-				}
-				sh.mu.Unlock()
+			start := time.Now()
+			evicted := s.sweep(start)
+			if fn := s.onSweep.Load(); fn != nil {
+				(*fn)(time.Since(start), evicted)
 			}
 
 		case <-s.janitorStop:
@@ -191,9 +230,60 @@ func (s *ShardedMap) janitor(interval time.Duration) {
 	}
 }
 
+// sweep removes entries expired at now and returns how many it removed.
+func (s *ShardedMap) sweep(now time.Time) int {
+	evicted := 0
+	for _, sh := range s.shards {
+		sh.mu.Lock()
+		for k, v := range sh.m {
+			if v.isExpired(now) {
+				delete(sh.m, k)
+				evicted++
+			}
+		}
+		sh.mu.Unlock()
+	}
+	return evicted
+}
+
+// OnSweep sets the observer called after each janitor sweep.
+func (s *ShardedMap) OnSweep(fn SweepObserver) {
+	s.onSweep.Store(&fn)
+}
+
+// ShardLens returns the number of entries, expired or not, in each shard.
+func (s *ShardedMap) ShardLens() []int {
+	out := make([]int, len(s.shards))
+	for i, sh := range s.shards {
+		sh.mu.RLock()
+		out[i] = len(sh.m)
+		sh.mu.RUnlock()
+	}
+	return out
+}
+
+// Ping reports whether the store can serve requests: every shard can be
+// locked, and the store hasn't been closed.
+func (s *ShardedMap) Ping() error {
+	if s.closed.Load() {
+		return ErrClosed
+	}
+	for _, sh := range s.shards {
+		sh.mu.RLock()
+		sh.mu.RUnlock()
+	}
+	return nil
+}
+
+// JanitorRunning reports whether expired entries are still being swept.
+func (s *ShardedMap) JanitorRunning() bool {
+	return s.janitorRunning.Load()
+}
+
 // Close stops the janitor goroutine.
 func (s *ShardedMap) Close() {
 	s.stopOnce.Do(func() {
+		s.closed.Store(true)
 		close(s.janitorStop)
 	})
 }
@@ -0,0 +1,181 @@
+// Package stream fans decisions out to live subscribers, e.g. the admin SSE
+// endpoint. Publishing never blocks: a subscriber that falls behind misses
+// events, which are counted as dropped.
+package stream
+
+import (
+	"math/rand"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"fibre_rate_limit_service/internal/decision"
+)
+
+// subscriberBuffer is how many events a subscriber may fall behind by.
+const subscriberBuffer = 256
+
+// Event is one decision as seen by subscribers.
+type Event struct {
+	Time      time.Time `json:"time"`
+	Route     string    `json:"route"`
+	Key       string    `json:"key"`
+	Limiter   string    `json:"limiter,omitempty"`
+	Allowed   bool      `json:"allowed"`
+	Outcome   string    `json:"outcome"`
+	Remaining *int      `json:"remaining,omitempty"` // set when a limiter applied
+	Reason    string    `json:"reason,omitempty"`
+	// ShadowOutcome and ShadowReason describe what shadow-mode rules or
+	// limiters would have decided, when they would have denied the request.
+	ShadowOutcome string `json:"shadow_outcome,omitempty"`
+	ShadowReason  string `json:"shadow_reason,omitempty"`
+}
+
+// Filter selects the events a subscriber receives. Zero fields match
+// everything.
+type Filter struct {
+	Route     string
+	KeyPrefix string
+	// DeniedOnly keeps denied requests, and those shadow mode would have
+	// denied.
+	DeniedOnly bool
+	// Sample is the fraction of matching events to keep, in (0, 1]; zero
+	// keeps all of them.
+	Sample float64
+}
+
+// Match reports whether e passes the filter, before sampling.
+func (f Filter) Match(e Event) bool {
+	switch {
+	case f.Route != "" && e.Route != f.Route:
+		return false
+	case f.KeyPrefix != "" && !strings.HasPrefix(e.Key, f.KeyPrefix):
+		return false
+	case f.DeniedOnly && e.Allowed && e.ShadowOutcome == "":
+		return false
+	}
+	return true
+}
+
+// Subscription receives events until it is closed.
+type Subscription struct {
+	C <-chan Event
+
+	c       chan Event
+	filter  Filter
+	dropped atomic.Uint64
+	hub     *Hub
+}
+
+// Dropped returns how many matching events were missed because the
+// subscriber fell behind.
+func (s *Subscription) Dropped() uint64 {
+	return s.dropped.Load()
+}
+
+// Close unsubscribes. C is closed once no more events will be sent.
+func (s *Subscription) Close() {
+	s.hub.remove(s)
+}
+
+// Hub distributes decisions to subscribers.
+type Hub struct {
+	mu     sync.RWMutex
+	subs   map[*Subscription]struct{}
+	n      atomic.Int32 // len(subs), read without the lock by Observe
+	closed bool
+}
+
+// NewHub creates a hub with no subscribers.
+func NewHub() *Hub {
+	return &Hub{subs: make(map[*Subscription]struct{})}
+}
+
+// Subscribe starts receiving events matching f. It returns nil once the hub
+// is closed.
+func (h *Hub) Subscribe(f Filter) *Subscription {
+	c := make(chan Event, subscriberBuffer)
+	s := &Subscription{C: c, c: c, filter: f, hub: h}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.closed {
+		return nil
+	}
+	h.subs[s] = struct{}{}
+	h.n.Add(1)
+	return s
+}
+
+func (h *Hub) remove(s *Subscription) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if _, ok := h.subs[s]; ok {
+		delete(h.subs, s)
+		h.n.Add(-1)
+		close(s.c)
+	}
+}
+
+// Close ends every subscription and refuses new ones, e.g. on shutdown.
+func (h *Hub) Close() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.closed = true
+	for s := range h.subs {
+		delete(h.subs, s)
+		close(s.c)
+	}
+	h.n.Store(0)
+}
+
+// Observe is a decision.Observer publishing each decision. It costs nothing
+// beyond an atomic load while nobody is subscribed.
+func (h *Hub) Observe(req decision.Request, d decision.Decision) {
+	if h.n.Load() == 0 {
+		return
+	}
+	h.Publish(NewEvent(req, d))
+}
+
+// Publish sends e to every subscriber whose filter matches.
+func (h *Hub) Publish(e Event) {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	for s := range h.subs {
+		if !s.filter.Match(e) {
+			continue
+		}
+		if s.filter.Sample > 0 && s.filter.Sample < 1 && rand.Float64() >= s.filter.Sample {
+			continue
+		}
+		select {
+		case s.c <- e:
+		default:
+			s.dropped.Add(1)
+		}
+	}
+}
+
+// NewEvent describes a decision. Strings are copied, since the request's may
+// be reused once the handler returns.
+func NewEvent(req decision.Request, d decision.Decision) Event {
+	e := Event{
+		Time:    time.Now(),
+		Route:   strings.Clone(req.Route),
+		Key:     strings.Clone(req.ClientID),
+		Limiter: strings.Clone(d.Limiter),
+		Allowed: d.Allowed(),
+		Outcome: d.Outcome.String(),
+		Reason:  d.Reason,
+	}
+	if d.Limited {
+		remaining := d.Result.Remaining
+		e.Remaining = &remaining
+	}
+	if d.Shadowed() {
+		e.ShadowOutcome, e.ShadowReason = d.ShadowOutcome.String(), d.ShadowReason
+	}
+	return e
+}
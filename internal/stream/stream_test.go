@@ -0,0 +1,60 @@
+package stream
+
+import (
+	"testing"
+
+	"fibre_rate_limit_service/internal/decision"
+	"fibre_rate_limit_service/internal/limiters"
+)
+
+func TestHubFiltersAndDrops(t *testing.T) {
+	h := NewHub()
+	all := h.Subscribe(Filter{})
+	denied := h.Subscribe(Filter{Route: "/check", KeyPrefix: "team-", DeniedOnly: true})
+	defer all.Close()
+
+	limited := decision.Decision{Outcome: decision.RateLimited, Limited: true, Limiter: "/check", Result: limiters.Result{Remaining: 0}}
+	h.Observe(decision.Request{ClientID: "team-a", Route: "/check"}, limited)
+	h.Observe(decision.Request{ClientID: "other", Route: "/check"}, limited)
+	h.Observe(decision.Request{ClientID: "team-b", Route: "/check"}, decision.Decision{Outcome: decision.Allowed})
+
+	if got := len(all.C); got != 3 {
+		t.Errorf("unfiltered subscriber got %d events, want 3", got)
+	}
+	if got := len(denied.C); got != 1 {
+		t.Fatalf("filtered subscriber got %d events, want 1", got)
+	}
+	e := <-denied.C
+	if e.Key != "team-a" || e.Outcome != "rate_limited" || e.Remaining == nil || *e.Remaining != 0 {
+		t.Errorf("unexpected event %+v", e)
+	}
+
+	for i := 0; i < subscriberBuffer; i++ {
+		h.Publish(Event{Route: "/check"})
+	}
+	if all.Dropped() != 3 {
+		t.Errorf("dropped = %d, want 3", all.Dropped())
+	}
+
+	denied.Close()
+	if _, ok := <-denied.C; ok {
+		t.Error("channel still open after Close")
+	}
+	h.Close()
+	if h.Subscribe(Filter{}) != nil {
+		t.Error("subscribed to a closed hub")
+	}
+}
+
+func TestHubSampling(t *testing.T) {
+	h := NewHub()
+	s := h.Subscribe(Filter{Sample: 0.25})
+	defer s.Close()
+
+	for i := 0; i < 200; i++ {
+		h.Publish(Event{})
+	}
+	if n := len(s.C); n < 20 || n > 90 {
+		t.Errorf("sampled %d of 200 events at 0.25", n)
+	}
+}
@@ -0,0 +1,147 @@
+// Package tracing sets up OpenTelemetry tracing: the exporter chosen at
+// startup, W3C trace context propagation, and server spans for the check
+// endpoints. Decisions add child spans through decision.Decider.
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"fibre_rate_limit_service/internal/config"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/utils"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// tracerName identifies the spans this service creates.
+const tracerName = "fibre_rate_limit_service"
+
+// Config selects the exporter.
+type Config struct {
+	// Exporter is "" or "none" (tracing off), "stdout", "file:<path>", or
+	// "otlp", which sends to the OTLP/gRPC collector configured by the
+	// standard OTEL_EXPORTER_OTLP_* environment variables.
+	Exporter string
+	// SampleRatio is the fraction of new traces recorded; callers' sampling
+	// decisions are honored. Zero means 1.
+	SampleRatio float64
+}
+
+// Setup installs the global tracer provider and W3C propagators. The returned
+// function flushes and stops the exporter; it is a no-op when tracing is off.
+func Setup(ctx context.Context, cfg Config) (shutdown func(context.Context) error, err error) {
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(
+		propagation.TraceContext{}, propagation.Baggage{},
+	))
+
+	exporter, closer, err := newExporter(ctx, cfg.Exporter)
+	if err != nil || exporter == nil {
+		return func(context.Context) error { return nil }, err
+	}
+
+	ratio := cfg.SampleRatio
+	if ratio <= 0 || ratio > 1 {
+		ratio = 1
+	}
+	meta := config.GetMetadata()
+	tp := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(ratio))),
+		sdktrace.WithResource(resource.NewWithAttributes(semconv.SchemaURL,
+			semconv.ServiceName(meta.ServiceName),
+			semconv.ServiceVersion(meta.Version),
+		)),
+	)
+	otel.SetTracerProvider(tp)
+
+	return func(ctx context.Context) error {
+		err := tp.Shutdown(ctx)
+		if closer != nil {
+			if cerr := closer.Close(); err == nil {
+				err = cerr
+			}
+		}
+		return err
+	}, nil
+}
+
+func newExporter(ctx context.Context, spec string) (sdktrace.SpanExporter, io.Closer, error) {
+	switch {
+	case spec == "" || spec == "none":
+		return nil, nil, nil
+	case spec == "stdout":
+		exp, err := stdouttrace.New(stdouttrace.WithWriter(os.Stdout))
+		return exp, nil, err
+	case strings.HasPrefix(spec, "file:"):
+		f, err := os.OpenFile(strings.TrimPrefix(spec, "file:"), os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0o640)
+		if err != nil {
+			return nil, nil, err
+		}
+		exp, err := stdouttrace.New(stdouttrace.WithWriter(f))
+		if err != nil {
+			f.Close()
+			return nil, nil, err
+		}
+		return exp, f, nil
+	case spec == "otlp":
+		exp, err := otlptracegrpc.New(ctx)
+		return exp, nil, err
+	}
+	return nil, nil, fmt.Errorf("%w: trace exporter must be none, stdout, file:<path> or otlp, not %q", config.ErrInvalidConfig, spec)
+}
+
+// Middleware starts a server span for each request, continuing the caller's
+// trace from its traceparent header. Handlers reach the span through
+// c.UserContext(). The span is named after the route, e.g. "POST /check".
+// Spans are exported after the request, so the strings they keep are copied.
+func Middleware() fiber.Handler {
+	tracer := otel.Tracer(tracerName)
+	return func(c *fiber.Ctx) error {
+		method := utils.CopyString(c.Method())
+		ctx := otel.GetTextMapPropagator().Extract(c.UserContext(), headerCarrier{c})
+		ctx, span := tracer.Start(ctx, method+" "+c.Route().Path,
+			trace.WithSpanKind(trace.SpanKindServer),
+			trace.WithAttributes(
+				semconv.HTTPRequestMethodKey.String(method),
+				semconv.URLPath(utils.CopyString(c.Path())),
+			),
+		)
+		defer span.End()
+
+		c.SetUserContext(ctx)
+		err := c.Next()
+
+		status := c.Response().StatusCode()
+		span.SetAttributes(semconv.HTTPResponseStatusCode(status))
+		if err != nil || status >= fiber.StatusInternalServerError {
+			span.SetStatus(codes.Error, "")
+		}
+		return err
+	}
+}
+
+// headerCarrier adapts a request's headers for propagators.
+type headerCarrier struct{ c *fiber.Ctx }
+
+func (h headerCarrier) Get(key string) string { return utils.CopyString(h.c.Get(key)) }
+func (h headerCarrier) Set(key, value string) { h.c.Set(key, value) }
+
+func (h headerCarrier) Keys() []string {
+	var keys []string
+	h.c.Request().Header.VisitAll(func(k, _ []byte) {
+		keys = append(keys, string(k))
+	})
+	return keys
+}
@@ -0,0 +1,410 @@
+// Package client is a typed Go client for the rate limit service's HTTP API.
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// ErrUnavailable is returned (wrapped) when the service could not be reached
+// or kept failing after all retries.
+var ErrUnavailable = errors.New("rate limit service unavailable")
+
+// FailMode decides what Check reports when the service is unavailable.
+type FailMode int
+
+const (
+	// FailOpen allows requests while the service is unavailable.
+	FailOpen FailMode = iota
+	// FailClosed denies requests while the service is unavailable.
+	FailClosed
+)
+
+// Client talks to the /check and /admin endpoints.
+type Client struct {
+	baseURL  string
+	http     *http.Client
+	timeout  time.Duration
+	retries  int
+	backoff  time.Duration
+	failMode FailMode
+	token    string
+}
+
+// Option customises a Client.
+type Option func(*Client)
+
+// WithHTTPClient replaces the underlying *http.Client.
+func WithHTTPClient(hc *http.Client) Option {
+	return func(c *Client) { c.http = hc }
+}
+
+// WithTimeout bounds each attempt (default 2s).
+func WithTimeout(d time.Duration) Option {
+	return func(c *Client) { c.timeout = d }
+}
+
+// WithRetries sets how many times a failed attempt is retried and the initial
+// backoff, which doubles after every attempt (default 2 retries, 50ms).
+//
+// GET requests are retried on any transport error or 5xx response. Check and
+// the admin POST/PUT/DELETE calls are only retried when the connection could
+// not be established, as the service may already have consumed tokens or
+// applied the change.
+func WithRetries(n int, backoff time.Duration) Option {
+	return func(c *Client) {
+		c.retries = n
+		c.backoff = backoff
+	}
+}
+
+// WithFailMode sets the local fail-open/fail-closed policy (default FailOpen).
+func WithFailMode(m FailMode) Option {
+	return func(c *Client) { c.failMode = m }
+}
+
+// WithToken sends a bearer token for the admin API, which requires one when
+// the server has admin auth configured.
+func WithToken(token string) Option {
+	return func(c *Client) { c.token = token }
+}
+
+// New creates a client for the service at baseURL, e.g. "http://localhost:8080".
+func New(baseURL string, opts ...Option) *Client {
+	c := &Client{
+		baseURL:  strings.TrimRight(baseURL, "/"),
+		http:     http.DefaultClient,
+		timeout:  2 * time.Second,
+		retries:  2,
+		backoff:  50 * time.Millisecond,
+		failMode: FailOpen,
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+// CheckRequest describes a call to POST /check.
+type CheckRequest struct {
+	ClientID string
+	// Headers are forwarded so policy rules (e.g. X-Secret) can match.
+	Headers map[string]string
+}
+
+// CheckResult is the service's answer to a check.
+type CheckResult struct {
+	Allowed   bool      `json:"allowed"`
+	Remaining int       `json:"remaining"`
+	ResetAt   time.Time `json:"reset_at"`
+	Reason    string    `json:"reason"`
+
+	// StatusCode is the HTTP status returned by the service, or 0 when the
+	// result comes from the local fail mode.
+	StatusCode int `json:"-"`
+}
+
+// Check asks the service whether a request may proceed.
+//
+// When the service is unavailable Check still returns a result decided by the
+// fail mode, together with an error wrapping ErrUnavailable, so callers can
+// act on Allowed and log the error.
+func (c *Client) Check(ctx context.Context, req CheckRequest) (*CheckResult, error) {
+	headers := make(map[string]string, len(req.Headers)+1)
+	for k, v := range req.Headers {
+		headers[k] = v
+	}
+	if req.ClientID != "" {
+		headers["X-Client-ID"] = req.ClientID
+	}
+
+	var res CheckResult
+	status, err := c.do(ctx, http.MethodPost, "/check", headers, nil, &res)
+	if err != nil {
+		if errors.Is(err, ErrUnavailable) {
+			return &CheckResult{
+				Allowed: c.failMode == FailOpen,
+				Reason:  "rate limit service unavailable",
+			}, err
+		}
+		return nil, err
+	}
+
+	res.StatusCode = status
+	return &res, nil
+}
+
+// LimiterRequest mirrors the body of POST /admin/limiters.
+type LimiterRequest struct {
+	Name        string `json:"name"`
+	Type        string `json:"type"`
+	Capacity    int    `json:"capacity,omitempty"`
+	RefillRate  int    `json:"refill_rate,omitempty"`
+	RefillEvery int    `json:"refill_every,omitempty"` // seconds
+	Limit       int    `json:"limit,omitempty"`
+	Window      int    `json:"window,omitempty"` // seconds
+	TTL         int    `json:"ttl"`              // seconds
+	// Mode is "enforce" (the default) or "shadow", which only records the
+	// requests the limiter would reject.
+	Mode string `json:"mode,omitempty"`
+	// Overrides replace the limiter's per-key overrides. When empty the
+	// service keeps the ones it has.
+	Overrides []Override `json:"overrides,omitempty"`
+}
+
+// Override replaces a limiter's settings for one client key, or for every
+// key with a prefix when Key ends in "*". Set the fields of the limiter's
+// type.
+type Override struct {
+	Key         string `json:"key"`
+	Capacity    int    `json:"capacity,omitempty"`
+	RefillRate  int    `json:"refill_rate,omitempty"`
+	RefillEvery int    `json:"refill_every,omitempty"` // seconds
+	Limit       int    `json:"limit,omitempty"`
+	Window      int    `json:"window,omitempty"` // seconds
+	TTL         int    `json:"ttl,omitempty"`    // seconds
+}
+
+// SetLimiter adds or replaces a limiter.
+func (c *Client) SetLimiter(ctx context.Context, req LimiterRequest) error {
+	_, err := c.do(ctx, http.MethodPost, "/admin/limiters", nil, req, nil)
+	return err
+}
+
+// ListLimiters returns every limiter's type and config.
+func (c *Client) ListLimiters(ctx context.Context) ([]LimiterRequest, error) {
+	var out []LimiterRequest
+	_, err := c.do(ctx, http.MethodGet, "/admin/limiters", nil, nil, &out)
+	return out, err
+}
+
+// GetLimiter returns one limiter's type and config.
+func (c *Client) GetLimiter(ctx context.Context, name string) (*LimiterRequest, error) {
+	var out LimiterRequest
+	if _, err := c.do(ctx, http.MethodGet, "/admin/limiters/"+url.PathEscape(name), nil, nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// DeleteLimiter removes a limiter.
+func (c *Client) DeleteLimiter(ctx context.Context, name string) error {
+	_, err := c.do(ctx, http.MethodDelete, "/admin/limiters/"+url.PathEscape(name), nil, nil, nil)
+	return err
+}
+
+// KeyState is one client key's standing with a limiter.
+type KeyState struct {
+	Key       string    `json:"key"`
+	Limit     int       `json:"limit"`
+	Remaining int       `json:"remaining"`
+	ResetAt   time.Time `json:"reset_at"`
+}
+
+// GetKey returns a key's current state on a limiter.
+func (c *Client) GetKey(ctx context.Context, limiter, key string) (*KeyState, error) {
+	return c.keyOp(ctx, http.MethodGet, limiter, key, nil)
+}
+
+// ResetKey clears a key's state so it starts fresh.
+func (c *Client) ResetKey(ctx context.Context, limiter, key string) (*KeyState, error) {
+	return c.keyOp(ctx, http.MethodDelete, limiter, key, nil)
+}
+
+// GrantKey gives a key n extra requests.
+func (c *Client) GrantKey(ctx context.Context, limiter, key string, n int) (*KeyState, error) {
+	return c.keyOp(ctx, http.MethodPut, limiter, key, map[string]int{"tokens": n})
+}
+
+func (c *Client) keyOp(ctx context.Context, method, limiter, key string, body interface{}) (*KeyState, error) {
+	var out struct {
+		State KeyState `json:"state"`
+	}
+	path := "/admin/limiters/" + url.PathEscape(limiter) + "/keys/" + url.PathEscape(key)
+	if _, err := c.do(ctx, method, path, nil, body, &out); err != nil {
+		return nil, err
+	}
+	return &out.State, nil
+}
+
+// PolicyRequest mirrors the body of POST /admin/policies.
+type PolicyRequest struct {
+	Route  string `json:"route"`
+	ID     string `json:"id,omitempty"`
+	Header string `json:"header"`
+	Value  string `json:"value"`
+	Mode   string `json:"mode,omitempty"` // "enforce" (the default) or "shadow"
+}
+
+// Rule is a policy rule as returned by the admin API.
+type Rule struct {
+	ID     string `json:"id,omitempty"`
+	Header string `json:"header"`
+	Value  string `json:"value"`
+	Mode   string `json:"mode,omitempty"` // "enforce" (the default) or "shadow"
+}
+
+// AddPolicy adds (or updates, for the same header) a policy rule on a route.
+func (c *Client) AddPolicy(ctx context.Context, req PolicyRequest) (*Rule, error) {
+	var out struct {
+		Rule Rule `json:"rule"`
+	}
+	if _, err := c.do(ctx, http.MethodPost, "/admin/policies", nil, req, &out); err != nil {
+		return nil, err
+	}
+	return &out.Rule, nil
+}
+
+// ListPolicies returns every route's rules.
+func (c *Client) ListPolicies(ctx context.Context) (map[string][]Rule, error) {
+	var out map[string][]Rule
+	_, err := c.do(ctx, http.MethodGet, "/admin/policies", nil, nil, &out)
+	return out, err
+}
+
+// ReplacePolicies atomically swaps a route's entire rule set.
+func (c *Client) ReplacePolicies(ctx context.Context, route string, rules []Rule) ([]Rule, error) {
+	var out []Rule
+	_, err := c.do(ctx, http.MethodPut, "/admin/policies/"+url.PathEscape(route), nil, rules, &out)
+	return out, err
+}
+
+// DeletePolicyRule removes one rule from a route.
+func (c *Client) DeletePolicyRule(ctx context.Context, route, id string) error {
+	path := "/admin/policies/" + url.PathEscape(route) + "/rules/" + url.PathEscape(id)
+	_, err := c.do(ctx, http.MethodDelete, path, nil, nil, nil)
+	return err
+}
+
+// Snapshot returns the limiters' in-memory state.
+func (c *Client) Snapshot(ctx context.Context) (map[string]interface{}, error) {
+	var out map[string]interface{}
+	_, err := c.do(ctx, http.MethodGet, "/admin/snapshot", nil, nil, &out)
+	return out, err
+}
+
+// APIError is returned for 4xx responses other than the check outcomes.
+type APIError struct {
+	StatusCode int
+	Message    string
+}
+
+func (e *APIError) Error() string {
+	return fmt.Sprintf("rate limit service: %d %s", e.StatusCode, e.Message)
+}
+
+// do performs a request with per-attempt timeouts and retries on transport
+// errors and 5xx responses. For /check, 403 and 429 are decoded into out
+// rather than treated as errors.
+func (c *Client) do(ctx context.Context, method, path string, headers map[string]string, body, out interface{}) (int, error) {
+	var payload []byte
+	if body != nil {
+		var err error
+		if payload, err = json.Marshal(body); err != nil {
+			return 0, err
+		}
+	}
+
+	backoff := c.backoff
+	var lastErr error
+
+	for attempt := 0; attempt <= c.retries; attempt++ {
+		if attempt > 0 {
+			select {
+			case <-ctx.Done():
+				return 0, fmt.Errorf("%w: %v", ErrUnavailable, ctx.Err())
+			case <-time.After(backoff):
+			}
+			backoff *= 2
+		}
+
+		status, data, err := c.attempt(ctx, method, path, headers, payload)
+		if err != nil {
+			lastErr = err
+			if method == http.MethodGet || isDialError(err) {
+				continue
+			}
+			break
+		}
+		if status >= 500 {
+			lastErr = fmt.Errorf("status %d", status)
+			if method == http.MethodGet {
+				continue
+			}
+			break
+		}
+
+		isCheck := path == "/check" && (status == http.StatusForbidden || status == http.StatusTooManyRequests)
+		if status >= 400 && !isCheck {
+			var e struct {
+				Error string `json:"error"`
+			}
+			json.Unmarshal(data, &e)
+			return status, &APIError{StatusCode: status, Message: e.Error}
+		}
+
+		if out != nil && len(data) > 0 {
+			if err := json.Unmarshal(data, out); err != nil {
+				return status, err
+			}
+		}
+		return status, nil
+	}
+
+	return 0, fmt.Errorf("%w: %v", ErrUnavailable, lastErr)
+}
+
+// isDialError reports whether err happened before a connection to the service
+// was established, so the request was never sent.
+func isDialError(err error) bool {
+	var op *net.OpError
+	return errors.As(err, &op) && op.Op == "dial"
+}
+
+func (c *Client) attempt(ctx context.Context, method, path string, headers map[string]string, payload []byte) (int, []byte, error) {
+	if c.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, c.timeout)
+		defer cancel()
+	}
+
+	var body io.Reader
+	if payload != nil {
+		body = bytes.NewReader(payload)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, c.baseURL+path, body)
+	if err != nil {
+		return 0, nil, err
+	}
+	if payload != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if c.token != "" && strings.HasPrefix(path, "/admin/") {
+		req.Header.Set("Authorization", "Bearer "+c.token)
+	}
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return 0, nil, err
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return 0, nil, err
+	}
+	return resp.StatusCode, data, nil
+}
@@ -0,0 +1,76 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestClient_GetRetriesThenSucceeds(t *testing.T) {
+	var calls int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&calls, 1) == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Write([]byte(`[{"name":"orders","type":"token_bucket","ttl":60}]`))
+	}))
+	defer srv.Close()
+
+	c := New(srv.URL, WithRetries(2, time.Millisecond))
+	out, err := c.ListLimiters(context.Background())
+	if err != nil {
+		t.Fatalf("ListLimiters: %v", err)
+	}
+	if len(out) != 1 || out[0].Name != "orders" {
+		t.Fatalf("unexpected limiters %+v", out)
+	}
+	if calls != 2 {
+		t.Fatalf("expected 2 attempts, got %d", calls)
+	}
+}
+
+func TestClient_CheckNotRetriedAfterServerError(t *testing.T) {
+	var calls int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		if r.Header.Get("X-Client-ID") != "c1" {
+			t.Errorf("expected X-Client-ID c1, got %q", r.Header.Get("X-Client-ID"))
+		}
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer srv.Close()
+
+	c := New(srv.URL, WithRetries(2, time.Millisecond), WithFailMode(FailClosed))
+	res, err := c.Check(context.Background(), CheckRequest{ClientID: "c1"})
+	if !errors.Is(err, ErrUnavailable) {
+		t.Fatalf("expected ErrUnavailable, got %v", err)
+	}
+	if res.Allowed {
+		t.Fatalf("unexpected result %+v", res)
+	}
+	if calls != 1 {
+		t.Fatalf("expected 1 attempt, got %d", calls)
+	}
+}
+
+func TestClient_FailModes(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	url := srv.URL
+	srv.Close()
+
+	for _, mode := range []FailMode{FailOpen, FailClosed} {
+		c := New(url, WithRetries(1, time.Millisecond), WithFailMode(mode))
+		res, err := c.Check(context.Background(), CheckRequest{ClientID: "c1"})
+		if !errors.Is(err, ErrUnavailable) {
+			t.Fatalf("expected ErrUnavailable, got %v", err)
+		}
+		if res.Allowed != (mode == FailOpen) {
+			t.Fatalf("mode %v: unexpected Allowed=%v", mode, res.Allowed)
+		}
+	}
+}
@@ -0,0 +1,71 @@
+package client
+
+import (
+	"context"
+	"io"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"fibre_rate_limit_service/internal/audit"
+	rlhttp "fibre_rate_limit_service/internal/http"
+	"fibre_rate_limit_service/internal/limiters"
+	"fibre_rate_limit_service/internal/policies"
+	"fibre_rate_limit_service/internal/storage"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/middleware/adaptor"
+)
+
+// Reading a shadow limiter or rule and writing it back keeps it in shadow
+// mode, with its overrides.
+func TestClient_RoundTripKeepsShadowMode(t *testing.T) {
+	store := storage.NewShardedMap(4, time.Minute, time.Minute)
+	defer store.Close()
+	app := fiber.New()
+	rlhttp.SetupRouter(app, rlhttp.Services{
+		Limiters: limiters.NewManager(),
+		Policies: policies.NewEvaluator(),
+		Store:    store,
+		Audit:    audit.New(io.Discard),
+	})
+	srv := httptest.NewServer(adaptor.FiberApp(app))
+	defer srv.Close()
+
+	c := New(srv.URL)
+	ctx := context.Background()
+
+	err := c.SetLimiter(ctx, LimiterRequest{
+		Name: "/orders", Type: "fixed-window", Limit: 5, Window: 60, TTL: 60, Mode: "shadow",
+		Overrides: []Override{{Key: "acme:*", Limit: 50, Window: 60}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	l, err := c.GetLimiter(ctx, "/orders")
+	if err != nil {
+		t.Fatal(err)
+	}
+	l.Limit = 10
+	if err := c.SetLimiter(ctx, *l); err != nil {
+		t.Fatal(err)
+	}
+	if l, err = c.GetLimiter(ctx, "/orders"); err != nil || l.Mode != "shadow" || l.Limit != 10 ||
+		len(l.Overrides) != 1 || l.Overrides[0] != (Override{Key: "acme:*", Limit: 50, Window: 60}) {
+		t.Fatalf("limiter after round trip: %+v %v", l, err)
+	}
+
+	if _, err := c.AddPolicy(ctx, PolicyRequest{Route: "/orders", Header: "X-Secret", Value: "1", Mode: "shadow"}); err != nil {
+		t.Fatal(err)
+	}
+	all, err := c.ListPolicies(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.ReplacePolicies(ctx, "/orders", all["/orders"]); err != nil {
+		t.Fatal(err)
+	}
+	if all, err = c.ListPolicies(ctx); err != nil || len(all["/orders"]) != 1 || all["/orders"][0].Mode != "shadow" {
+		t.Fatalf("rules after round trip: %+v %v", all, err)
+	}
+}
@@ -0,0 +1,145 @@
+// Package fibermw enforces the service's policies and limiters in-process
+// for Fiber applications. Build the limiters and policies with package
+// ratelimit.
+package fibermw
+
+import (
+	"fibre_rate_limit_service/internal/decision"
+	"fibre_rate_limit_service/internal/http/middleware"
+	"fibre_rate_limit_service/internal/policies"
+	"fibre_rate_limit_service/pkg/ratelimit"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// decisionKey is the c.Locals key holding the request's decision.Decision.
+const decisionKey = "ratelimit.decision"
+
+// RateLimitConfig configures the RateLimit middleware.
+type RateLimitConfig struct {
+	// Limiters and Policies are consulted for every request. Required.
+	Limiters *ratelimit.Manager
+	Policies *ratelimit.Evaluator
+
+	// Next skips the middleware when it returns true.
+	//
+	// Optional. Default: nil
+	Next func(c *fiber.Ctx) bool
+
+	// KeyGenerator returns the client key passed to the limiter.
+	//
+	// Optional. Default: ClientID
+	KeyGenerator func(c *fiber.Ctx) string
+
+	// RouteGenerator returns the route used to look up limiters and policies.
+	//
+	// Optional. Default: c.Path()
+	RouteGenerator func(c *fiber.Ctx) string
+
+	// LimitReached is called when the limiter rejects the request. The
+	// decision is available through DecisionFrom.
+	//
+	// Optional. Default: 429 with a JSON body
+	LimitReached fiber.Handler
+
+	// PolicyDenied is called when a policy rejects the request.
+	//
+	// Optional. Default: 403 with a JSON body
+	PolicyDenied fiber.Handler
+
+	// DisableHeaders turns off the X-RateLimit-* response headers.
+	//
+	// Optional. Default: false
+	DisableHeaders bool
+}
+
+// RateLimit enforces policies and limiters in-process as a fiber.Handler.
+func RateLimit(cfg RateLimitConfig) fiber.Handler {
+	if cfg.Limiters == nil || cfg.Policies == nil {
+		panic("fibermw: RateLimit requires Limiters and Policies")
+	}
+	if cfg.KeyGenerator == nil {
+		cfg.KeyGenerator = ClientID
+	}
+	if cfg.RouteGenerator == nil {
+		cfg.RouteGenerator = func(c *fiber.Ctx) string {
+			return c.Path()
+		}
+	}
+	if cfg.LimitReached == nil {
+		cfg.LimitReached = denied(fiber.StatusTooManyRequests)
+	}
+	if cfg.PolicyDenied == nil {
+		cfg.PolicyDenied = denied(fiber.StatusForbidden)
+	}
+
+	d := decision.NewDecider(cfg.Limiters, cfg.Policies)
+
+	return func(c *fiber.Ctx) error {
+		if cfg.Next != nil && cfg.Next(c) {
+			return c.Next()
+		}
+
+		res := d.DecideContext(c.UserContext(), decision.Request{
+			ID:       middleware.RequestID(c),
+			ClientID: cfg.KeyGenerator(c),
+			Route:    cfg.RouteGenerator(c),
+			Method:   c.Method(),
+			Headers:  Headers(c),
+		})
+		c.Locals(decisionKey, res)
+
+		if !cfg.DisableHeaders {
+			SetRateLimitHeaders(c, res)
+		}
+
+		switch res.Outcome {
+		case ratelimit.PolicyDenied:
+			return cfg.PolicyDenied(c)
+		case ratelimit.RateLimited:
+			return cfg.LimitReached(c)
+		}
+		return c.Next()
+	}
+}
+
+// DecisionFrom returns the decision RateLimit stored for this request.
+func DecisionFrom(c *fiber.Ctx) (ratelimit.Decision, bool) {
+	d, ok := c.Locals(decisionKey).(ratelimit.Decision)
+	return d, ok
+}
+
+func denied(status int) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		d, _ := DecisionFrom(c)
+		return c.Status(status).JSON(fiber.Map{
+			"allowed": false,
+			"reason":  d.Reason,
+		})
+	}
+}
+
+// ClientID identifies the caller from the X-Client-ID header, falling back
+// to "anonymous".
+func ClientID(c *fiber.Ctx) string {
+	id := c.Get("X-Client-ID")
+	if id == "" {
+		id = "anonymous"
+	}
+	return id
+}
+
+// Headers exposes the incoming request headers to policy evaluation.
+func Headers(c *fiber.Ctx) policies.Headers {
+	return policies.HeaderFunc(func(name string) string {
+		return c.Get(name)
+	})
+}
+
+// SetRateLimitHeaders writes the X-RateLimit-* headers (and Retry-After when
+// throttled) describing the limiter's answer.
+func SetRateLimitHeaders(c *fiber.Ctx, d ratelimit.Decision) {
+	d.WriteHeaders(func(name, value string) {
+		c.Set(name, value)
+	})
+}
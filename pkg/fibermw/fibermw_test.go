@@ -0,0 +1,130 @@
+package fibermw_test
+
+import (
+	"io"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"fibre_rate_limit_service/pkg/fibermw"
+	"fibre_rate_limit_service/pkg/ratelimit"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func TestRateLimit(t *testing.T) {
+	store := ratelimit.NewStore(4, time.Minute, time.Minute)
+	defer store.Close()
+
+	lm := ratelimit.NewManager()
+	lm.SetLimiter("/orders", ratelimit.NewTokenBucket(ratelimit.TokenBucketConfig{
+		Name:        "/orders",
+		Capacity:    1,
+		RefillRate:  1,
+		RefillEvery: time.Hour,
+	}, store))
+	pe := ratelimit.NewEvaluator()
+	pe.AddRule("/admin", ratelimit.Rule{Header: "X-Secret", Value: "123"})
+
+	app := fiber.New()
+	app.Use(fibermw.RateLimit(fibermw.RateLimitConfig{
+		Limiters: lm,
+		Policies: pe,
+		Next: func(c *fiber.Ctx) bool {
+			return c.Get("X-Internal") == "true"
+		},
+		KeyGenerator: func(c *fiber.Ctx) string {
+			return c.Query("tenant")
+		},
+		LimitReached: func(c *fiber.Ctx) error {
+			d, _ := fibermw.DecisionFrom(c)
+			return c.Status(fiber.StatusTooManyRequests).SendString("slow down: " + d.Outcome.String())
+		},
+		PolicyDenied: func(c *fiber.Ctx) error {
+			return c.Status(fiber.StatusUnauthorized).SendString("who are you")
+		},
+	}))
+	// Skipped requests carry no decision
+	app.Get("/*", func(c *fiber.Ctx) error {
+		d, ok := fibermw.DecisionFrom(c)
+		switch {
+		case !ok:
+			return c.SendString("skipped")
+		case d.Outcome != ratelimit.Allowed:
+			return c.Status(fiber.StatusInternalServerError).SendString(d.Outcome.String())
+		}
+		return c.SendString("ok")
+	})
+
+	cases := []struct {
+		name     string
+		url      string
+		headers  map[string]string
+		status   int
+		body     string
+		withHdrs bool
+	}{
+		{"allowed", "/orders?tenant=a", nil, fiber.StatusOK, "ok", true},
+		{"custom LimitReached", "/orders?tenant=a", nil, fiber.StatusTooManyRequests, "slow down: rate_limited", true},
+		{"separate key", "/orders?tenant=b", nil, fiber.StatusOK, "ok", true},
+		{"Next skips limiter", "/orders?tenant=a", map[string]string{"X-Internal": "true"}, fiber.StatusOK, "skipped", false},
+		{"custom PolicyDenied", "/admin", nil, fiber.StatusUnauthorized, "who are you", false},
+		{"Next skips policy", "/admin", map[string]string{"X-Internal": "true"}, fiber.StatusOK, "skipped", false},
+		{"policy passed", "/admin", map[string]string{"X-Secret": "123"}, fiber.StatusOK, "ok", false},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest(fiber.MethodGet, tc.url, nil)
+			for k, v := range tc.headers {
+				req.Header.Set(k, v)
+			}
+			resp, err := app.Test(req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			body, _ := io.ReadAll(resp.Body)
+			if resp.StatusCode != tc.status || string(body) != tc.body {
+				t.Fatalf("got %d %q, want %d %q", resp.StatusCode, body, tc.status, tc.body)
+			}
+			if got := resp.Header.Get("X-RateLimit-Limit") == "1"; got != tc.withHdrs {
+				t.Fatalf("X-RateLimit-Limit present = %v, want %v", got, tc.withHdrs)
+			}
+		})
+	}
+}
+
+func TestRateLimit_Defaults(t *testing.T) {
+	store := ratelimit.NewStore(4, time.Minute, time.Minute)
+	defer store.Close()
+	lm := ratelimit.NewManager()
+	lm.SetLimiter("/orders", ratelimit.NewFixedWindow(ratelimit.FixedWindowConfig{
+		Name:   "/orders",
+		Limit:  1,
+		Window: time.Hour,
+	}, store))
+	pe := ratelimit.NewEvaluator()
+	pe.AddRule("/admin", ratelimit.Rule{Header: "X-Secret", Value: "123"})
+
+	app := fiber.New()
+	app.Use(fibermw.RateLimit(fibermw.RateLimitConfig{Limiters: lm, Policies: pe}))
+	app.Get("/*", func(c *fiber.Ctx) error {
+		return c.SendString("ok")
+	})
+
+	for i, tc := range []struct {
+		url    string
+		status int
+	}{
+		{"/orders", fiber.StatusOK},
+		{"/orders", fiber.StatusTooManyRequests},
+		{"/admin", fiber.StatusForbidden},
+	} {
+		resp, err := app.Test(httptest.NewRequest(fiber.MethodGet, tc.url, nil))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if resp.StatusCode != tc.status {
+			t.Fatalf("case %d: status %d, want %d", i, resp.StatusCode, tc.status)
+		}
+	}
+}
@@ -0,0 +1,136 @@
+// Package nethttp enforces the service's policies and limiters in-process
+// for applications built on net/http. Build the limiters and policies with
+// package ratelimit.
+package nethttp
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+
+	"fibre_rate_limit_service/internal/decision"
+	"fibre_rate_limit_service/pkg/ratelimit"
+)
+
+type contextKey struct{}
+
+// Config configures Middleware.
+type Config struct {
+	// Limiters and Policies are consulted for every request. Required.
+	Limiters *ratelimit.Manager
+	Policies *ratelimit.Evaluator
+
+	// Next skips the middleware when it returns true.
+	//
+	// Optional. Default: nil
+	Next func(r *http.Request) bool
+
+	// KeyFunc returns the client key passed to the limiter.
+	//
+	// Optional. Default: ClientID
+	KeyFunc func(r *http.Request) string
+
+	// RouteFunc returns the route used to look up limiters and policies.
+	//
+	// Optional. Default: r.URL.Path
+	RouteFunc func(r *http.Request) string
+
+	// LimitReached handles requests rejected by the limiter. The decision is
+	// available through DecisionFrom(r.Context()).
+	//
+	// Optional. Default: 429 with a JSON body
+	LimitReached http.Handler
+
+	// PolicyDenied handles requests rejected by a policy.
+	//
+	// Optional. Default: 403 with a JSON body
+	PolicyDenied http.Handler
+
+	// DisableHeaders turns off the X-RateLimit-* response headers.
+	//
+	// Optional. Default: false
+	DisableHeaders bool
+}
+
+// Middleware returns a func(http.Handler) http.Handler that checks every
+// request against the configured policies and limiters.
+func Middleware(cfg Config) func(http.Handler) http.Handler {
+	if cfg.Limiters == nil || cfg.Policies == nil {
+		panic("nethttp: Middleware requires Limiters and Policies")
+	}
+	if cfg.KeyFunc == nil {
+		cfg.KeyFunc = ClientID
+	}
+	if cfg.RouteFunc == nil {
+		cfg.RouteFunc = func(r *http.Request) string {
+			return r.URL.Path
+		}
+	}
+	if cfg.LimitReached == nil {
+		cfg.LimitReached = denied(http.StatusTooManyRequests)
+	}
+	if cfg.PolicyDenied == nil {
+		cfg.PolicyDenied = denied(http.StatusForbidden)
+	}
+
+	d := decision.NewDecider(cfg.Limiters, cfg.Policies)
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if cfg.Next != nil && cfg.Next(r) {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			res := d.DecideContext(r.Context(), decision.Request{
+				ID:       r.Header.Get("X-Request-ID"),
+				ClientID: cfg.KeyFunc(r),
+				Route:    cfg.RouteFunc(r),
+				Method:   r.Method,
+				Headers:  r.Header,
+			})
+			r = r.WithContext(context.WithValue(r.Context(), contextKey{}, res))
+
+			if !cfg.DisableHeaders {
+				res.WriteHeaders(w.Header().Set)
+			}
+
+			switch res.Outcome {
+			case ratelimit.PolicyDenied:
+				cfg.PolicyDenied.ServeHTTP(w, r)
+			case ratelimit.RateLimited:
+				cfg.LimitReached.ServeHTTP(w, r)
+			default:
+				next.ServeHTTP(w, r)
+			}
+		})
+	}
+}
+
+// DecisionFrom returns the decision Middleware stored in the request context.
+func DecisionFrom(ctx context.Context) (ratelimit.Decision, bool) {
+	d, ok := ctx.Value(contextKey{}).(ratelimit.Decision)
+	return d, ok
+}
+
+// ClientID identifies the caller from the X-Client-ID header, falling back
+// to "anonymous".
+func ClientID(r *http.Request) string {
+	id := r.Header.Get("X-Client-ID")
+	if id == "" {
+		id = "anonymous"
+	}
+	return id
+}
+
+func denied(status int) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		d, _ := DecisionFrom(r.Context())
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(status)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"allowed": false,
+			"reason":  d.Reason,
+		})
+	})
+}
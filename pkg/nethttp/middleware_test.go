@@ -0,0 +1,111 @@
+package nethttp_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"fibre_rate_limit_service/pkg/nethttp"
+	"fibre_rate_limit_service/pkg/ratelimit"
+)
+
+func TestMiddleware(t *testing.T) {
+	store := ratelimit.NewStore(4, time.Minute, time.Minute)
+	defer store.Close()
+
+	lm := ratelimit.NewManager()
+	lm.SetLimiter("/orders", ratelimit.NewTokenBucket(ratelimit.TokenBucketConfig{
+		Name:        "/orders",
+		Capacity:    1,
+		RefillRate:  1,
+		RefillEvery: time.Hour,
+	}, store))
+	pe := ratelimit.NewEvaluator()
+	pe.AddRule("/admin", ratelimit.Rule{Header: "X-Secret", Value: "123"})
+
+	var seen ratelimit.Decision
+	ok := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		seen, _ = nethttp.DecisionFrom(r.Context())
+		w.Write([]byte("ok"))
+	})
+	h := nethttp.Middleware(nethttp.Config{
+		Limiters: lm,
+		Policies: pe,
+		Next: func(r *http.Request) bool {
+			return r.Header.Get("X-Internal") == "true"
+		},
+	})(ok)
+
+	cases := []struct {
+		name    string
+		path    string
+		headers map[string]string
+		status  int
+		// remaining is the expected X-RateLimit-Remaining; "" expects none
+		remaining string
+	}{
+		{"allowed", "/orders", nil, http.StatusOK, "0"},
+		{"limited", "/orders", nil, http.StatusTooManyRequests, "0"},
+		{"other key", "/orders", map[string]string{"X-Client-ID": "b"}, http.StatusOK, "0"},
+		{"skipped", "/orders", map[string]string{"X-Internal": "true"}, http.StatusOK, ""},
+		{"policy denied", "/admin", nil, http.StatusForbidden, ""},
+		{"policy passed", "/admin", map[string]string{"X-Secret": "123"}, http.StatusOK, ""},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, tc.path, nil)
+			for k, v := range tc.headers {
+				req.Header.Set(k, v)
+			}
+			rec := httptest.NewRecorder()
+			h.ServeHTTP(rec, req)
+
+			if rec.Code != tc.status {
+				t.Fatalf("status %d, want %d", rec.Code, tc.status)
+			}
+			if got := rec.Header().Get("X-RateLimit-Remaining"); got != tc.remaining {
+				t.Fatalf("X-RateLimit-Remaining %q, want %q", got, tc.remaining)
+			}
+			if tc.status == http.StatusTooManyRequests && rec.Header().Get("Retry-After") == "" {
+				t.Fatal("429 without Retry-After")
+			}
+		})
+	}
+	if seen.Outcome != ratelimit.Allowed {
+		t.Fatalf("DecisionFrom in handler: %+v", seen)
+	}
+}
+
+func TestMiddleware_CustomHandlers(t *testing.T) {
+	store := ratelimit.NewStore(4, time.Minute, time.Minute)
+	defer store.Close()
+	lm := ratelimit.NewManager()
+	lm.SetLimiter("/orders", ratelimit.NewFixedWindow(ratelimit.FixedWindowConfig{
+		Name:   "/orders",
+		Limit:  1,
+		Window: time.Hour,
+	}, store))
+
+	h := nethttp.Middleware(nethttp.Config{
+		Limiters: lm,
+		Policies: ratelimit.NewEvaluator(),
+		LimitReached: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			d, _ := nethttp.DecisionFrom(r.Context())
+			w.WriteHeader(http.StatusServiceUnavailable)
+			w.Write([]byte(d.Outcome.String()))
+		}),
+		DisableHeaders: true,
+	})(http.NotFoundHandler())
+
+	for i, want := range []int{http.StatusNotFound, http.StatusServiceUnavailable} {
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/orders", nil))
+		if rec.Code != want {
+			t.Fatalf("request %d: status %d, want %d", i, rec.Code, want)
+		}
+		if rec.Header().Get("X-RateLimit-Limit") != "" {
+			t.Fatalf("request %d: headers written despite DisableHeaders", i)
+		}
+	}
+}
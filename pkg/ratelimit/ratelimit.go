@@ -0,0 +1,83 @@
+// Package ratelimit exposes the service's storage, limiters, policies and
+// decisions to other modules, for use with the in-process middleware in
+// pkg/nethttp and pkg/fibermw. The types are aliases of the service's own, so
+// their methods (SetLimiter, AddRule, ...) are available as documented there.
+package ratelimit
+
+import (
+	"time"
+
+	"fibre_rate_limit_service/internal/config"
+	"fibre_rate_limit_service/internal/decision"
+	"fibre_rate_limit_service/internal/limiters"
+	"fibre_rate_limit_service/internal/policies"
+	"fibre_rate_limit_service/internal/storage"
+)
+
+type (
+	// Store holds limiter state in memory, sharded by key.
+	Store = storage.ShardedMap
+
+	// Limiter checks client keys against a limit.
+	Limiter = limiters.Limiter
+	// Result is a limiter's answer for one key.
+	Result = limiters.Result
+	// Manager maps routes to limiters.
+	Manager = limiters.Manager
+	// TokenBucketConfig configures NewTokenBucket.
+	TokenBucketConfig = limiters.TokenBucketConfig
+	// FixedWindowConfig configures NewFixedWindow.
+	FixedWindowConfig = limiters.FixedWindowConfig
+
+	// Evaluator holds the header rules per route.
+	Evaluator = policies.Evaluator
+	// Rule requires a header to have a value.
+	Rule = policies.Rule
+	// Mode is "enforce" (the default) or "shadow".
+	Mode = config.Mode
+
+	// Decision is the combined policy and limiter answer for a request.
+	Decision = decision.Decision
+	// Outcome says whether a request was allowed and, if not, why.
+	Outcome = decision.Outcome
+)
+
+// Decision outcomes
+const (
+	Allowed      = decision.Allowed
+	PolicyDenied = decision.PolicyDenied
+	RateLimited  = decision.RateLimited
+)
+
+// Rule and limiter modes
+const (
+	ModeEnforce = config.ModeEnforce
+	ModeShadow  = config.ModeShadow
+)
+
+// NewStore creates a store with the given number of shards, the TTL used
+// when a limiter doesn't set one, and how often expired entries are swept.
+// Close it to stop the sweeper.
+func NewStore(shards int, defaultTTL, cleanupInterval time.Duration) *Store {
+	return storage.NewShardedMap(shards, defaultTTL, cleanupInterval)
+}
+
+// NewManager creates a manager with no limiters.
+func NewManager() *Manager {
+	return limiters.NewManager()
+}
+
+// NewTokenBucket creates a token bucket limiter keeping its state in store.
+func NewTokenBucket(cfg TokenBucketConfig, store *Store) Limiter {
+	return limiters.NewTokenBucket(cfg, store)
+}
+
+// NewFixedWindow creates a fixed window limiter keeping its state in store.
+func NewFixedWindow(cfg FixedWindowConfig, store *Store) Limiter {
+	return limiters.NewFixedWindowLimiter(cfg, store)
+}
+
+// NewEvaluator creates an evaluator with no rules.
+func NewEvaluator() *Evaluator {
+	return policies.NewEvaluator()
+}